@@ -1,57 +1,371 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/fmenozzi/hn/src/api"
 	"github.com/fmenozzi/hn/src/cli"
+	"github.com/fmenozzi/hn/src/filter"
 	"github.com/fmenozzi/hn/src/formatting"
+	"github.com/fmenozzi/hn/src/sorting"
 )
 
-func FetchFrontPageItems(ranking api.FrontPageItemsRanking, limit int) ([]api.Item, error) {
-	client := api.MakeProdClient()
-	frontPageItemIds, err := client.FetchFrontPageItemIds(ranking, limit)
-	if err != nil {
-		return nil, err
-	}
-	frontPageItems, err := client.FetchItems(frontPageItemIds)
-	if err != nil {
-		return nil, err
+const (
+	// Exit status when only some of the requested items could be fetched.
+	exitPartialFailure = 3
+
+	// Fewest item ids fetched when filtering, in case many items are filtered
+	// out. This is all of those on the front page, but search has more.
+	maxFilteredIds = 500
+
+	// Fewest items fetched at a time when making up for filtered out items.
+	minFetchBatch = 30
+)
+
+// Makes a client for the production APIs, backed by the on-disk cache unless
+// useCache is unset. A cache that cannot be set up is simply skipped.
+func MakeClient(useCache bool) api.HnClient {
+	builder := api.NewProdHnClientBuilder()
+	if useCache {
+		if cache, err := api.NewProdCache(); err == nil {
+			builder.SetCache(cache)
+		}
 	}
-	return frontPageItems, nil
+	return builder.Build()
 }
 
-func FetchSearchItems(request api.SearchRequest) ([]api.Item, error) {
-	client := api.MakeProdClient()
-	searchResponse, err := client.Search(request)
-	if err != nil {
-		return nil, err
+// Fetches the given items. If allowPartial is set, the items that could be
+// fetched are returned even if others could not, along with an
+// `*api.FetchItemsError` describing the failures.
+func FetchItems(ctx context.Context, client *api.HnClient, ids []api.ItemId, allowPartial bool) ([]api.Item, error) {
+	if allowPartial {
+		return client.FetchItemsPartialContext(ctx, ids)
 	}
+	return client.FetchItemsContext(ctx, ids)
+}
+
+// Returns the ids of the search results, in order.
+func SearchItemIds(searchResponse *api.SearchResponse) []api.ItemId {
 	searchItemIds := make([]api.ItemId, len(searchResponse.Results))
 	for i, result := range searchResponse.Results {
 		searchItemIds[i] = result.Id
 	}
-	searchItems, err := client.FetchItems(searchItemIds)
+	return searchItemIds
+}
+
+// Returns the search results as items, as found by search rather than fetched
+// from HN.
+func SearchItems(searchResponse *api.SearchResponse) []api.Item {
+	searchItems := make([]api.Item, len(searchResponse.Results))
+	for i, result := range searchResponse.Results {
+		searchItems[i] = result.ToItem()
+	}
+	return searchItems
+}
+
+func FetchUser(ctx context.Context, client *api.HnClient, id string, submissions int, allowPartial bool) (*api.User, []api.Item, error) {
+	user, err := client.FetchUserContext(ctx, id)
 	if err != nil {
-		return nil, err
-	}
-	return searchItems, nil
-}
-
-func DisplayItems(items []api.Item, style formatting.Style) {
-	var clock formatting.RealClock
-	switch style {
-	case formatting.Plain:
-		formatting.WritePlain(items, &clock, os.Stdout)
-	case formatting.Markdown:
-		formatting.WriteMarkdown(items, &clock, os.Stdout)
-	case formatting.Json:
-		formatting.WriteJson(items, os.Stdout)
-	case formatting.Csv:
-		formatting.WriteCsv(items, os.Stdout)
-	default:
-		panic(fmt.Sprintf("invalid style: %s\n", style))
+		return nil, nil, err
+	}
+	if submissions == 0 {
+		return user, nil, nil
+	}
+	submittedItemIds := user.Submitted
+	if len(submittedItemIds) > submissions {
+		submittedItemIds = submittedItemIds[:submissions]
+	}
+	submittedItems, err := FetchItems(ctx, client, submittedItemIds, allowPartial)
+	if err != nil && !isPartialFailure(err) {
+		return nil, nil, err
+	}
+	return user, submittedItems, err
+}
+
+// Removes deleted and dead items, which are missing most of their fields.
+func RemoveDeadItems(items []api.Item) []api.Item {
+	return filter.Apply(items, filter.Live())
+}
+
+// Removes deleted and dead comments from the thread, except for those with
+// live replies, which are kept to preserve the shape of the thread.
+func RemoveDeadReplies(thread *api.Thread) {
+	liveReplies := []api.Thread{}
+	for _, reply := range thread.Replies {
+		RemoveDeadReplies(&reply)
+		if len(reply.Replies) > 0 || (!reply.IsDeleted() && !reply.IsDead()) {
+			liveReplies = append(liveReplies, reply)
+		}
+	}
+	thread.Replies = liveReplies
+}
+
+// Displays the user's profile, along with their submissions if any were asked
+// for, i.e. if submissions is not nil.
+func DisplayUser(user *api.User, submissions []api.Item, formatter formatting.Formatter) {
+	formatting.WriteUser(formatter, user, submissions, os.Stdout)
+}
+
+func FetchThread(ctx context.Context, client *api.HnClient, id api.ItemId, depth int, limit int) (*api.Thread, error) {
+	return client.FetchThreadContext(ctx, id, api.ThreadOptions{
+		MaxDepth:    depth,
+		MaxComments: limit,
+	})
+}
+
+func DisplayThread(thread *api.Thread, formatter formatting.Formatter) {
+	formatter.WriteThread(thread, os.Stdout)
+}
+
+func DisplayItems(items []api.Item, formatter formatting.Formatter) {
+	formatting.WriteItems(formatter, items, os.Stdout)
+}
+
+// Returns up to limit of the items that pass the filter, in order unless sorted
+// with a comparator.
+func SelectItems(items []api.Item, limit int, keep filter.Filter, order sorting.Comparator) []api.Item {
+	selected := filter.Apply(items, keep)
+	if len(selected) > limit {
+		selected = selected[:limit]
+	}
+	if order != nil {
+		sorting.Sort(selected, order)
+	}
+	return selected
+}
+
+// Fills in the story that each comment among the search results is on where
+// search does not know it, by walking up the comment's parents. Each story is
+// only fetched once, however many comments are on it. Stories that cannot be
+// fetched are left as far as search knows them, rather than failing the search.
+func ResolveCommentStories(ctx context.Context, client *api.HnClient, results []api.SearchResult) {
+	var ids []api.ItemId
+	unresolved := map[api.ItemId][]*api.SearchResult{}
+	for i := range results {
+		result := &results[i]
+		if result.ToItem().Type != api.Comment || (result.StoryId != nil && result.StoryTitle != nil) {
+			continue
+		}
+		id := result.Id
+		if result.StoryId != nil {
+			id = *result.StoryId
+		}
+		if _, ok := unresolved[id]; !ok {
+			ids = append(ids, id)
+		}
+		unresolved[id] = append(unresolved[id], result)
+	}
+
+	stories, _ := client.FetchRootItemsPartialContext(ctx, ids)
+	for i, story := range stories {
+		if story == nil {
+			continue
+		}
+		for _, result := range unresolved[ids[i]] {
+			result.StoryId, result.StoryTitle, result.StoryUrl = &story.Id, story.Title, story.Url
+		}
+	}
+}
+
+// Returns the search request for the given arguments, for up to limit results.
+func MakeSearchRequest(args cli.Args, limit int) api.SearchRequest {
+	request := api.SearchRequest{
+		Query:   args.Query,
+		Tags:    args.Tags,
+		Ranking: *args.RankingSearchResults,
+		Limit:   limit,
+		Since:   args.Since,
+		Until:   args.Until,
+	}
+	if args.MinPoints > 0 {
+		request.NumericFilters = append(request.NumericFilters, api.NumericFilter{
+			Attribute: api.Points,
+			Operator:  ">=",
+			Value:     int64(args.MinPoints),
+		})
+	}
+	return request
+}
+
+// Fetches and displays up to limit of the given items that pass the filter, in
+// order unless sorted with a comparator. Items are fetched in batches, so that
+// those filtered out are made up for by the ones after them. Streaming
+// formatters are handed each item as soon as it arrives, unless the items are
+// sorted, while others are handed all of them once fetched. As with
+// `FetchItems`, a partial failure still displays the items that were fetched.
+func FetchAndDisplayItems(ctx context.Context, client *api.HnClient, ids []api.ItemId, limit int, keep filter.Filter, order sorting.Comparator, allowPartial bool, formatter formatting.Formatter) error {
+	streaming := formatting.IsStreaming(formatter) && order == nil
+	if streaming {
+		formatter.Begin(os.Stdout)
+	}
+
+	var kept []api.Item
+	shown := 0
+	display := func(item api.Item) {
+		if shown >= limit || !keep(&item) {
+			return
+		}
+		shown++
+		if streaming {
+			formatter.WriteItem(&item, os.Stdout)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+
+	var failed *api.FetchItemsError
+	for len(ids) > 0 && shown < limit {
+		batch := ids[:min(len(ids), max(limit-shown, minFetchBatch))]
+		ids = ids[len(batch):]
+
+		var err error
+		if allowPartial {
+			err = client.StreamItemsPartialContext(ctx, batch, display)
+		} else {
+			err = client.StreamItemsContext(ctx, batch, display)
+		}
+		var batchErr *api.FetchItemsError
+		if errors.As(err, &batchErr) {
+			if failed == nil {
+				failed = &api.FetchItemsError{}
+			}
+			failed.Requested += batchErr.Requested
+			failed.Failures = append(failed.Failures, batchErr.Failures...)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	if streaming {
+		formatter.End(os.Stdout)
+	} else {
+		if order != nil {
+			sorting.Sort(kept, order)
+		}
+		DisplayItems(kept, formatter)
+	}
+	if failed != nil {
+		return failed
+	}
+	return nil
+}
+
+// Returns the filter of items to display for the given arguments, which omits
+// deleted and dead items unless asked not to.
+func MakeFilter(args cli.Args, now time.Time) filter.Filter {
+	var filters []filter.Filter
+	if !args.ShowDead {
+		filters = append(filters, filter.Live())
+	}
+	if args.MinScore > 0 {
+		filters = append(filters, filter.MinScore(args.MinScore))
+	}
+	if args.MinComments > 0 {
+		filters = append(filters, filter.MinComments(args.MinComments))
+	}
+	if args.MaxAge > 0 {
+		filters = append(filters, filter.MaxAge(args.MaxAge, now))
+	}
+	if len(args.Domains) > 0 {
+		filters = append(filters, filter.Domain(args.Domains...))
+	}
+	if len(args.ExcludeDomains) > 0 {
+		filters = append(filters, filter.ExcludeDomain(args.ExcludeDomains...))
+	}
+	if len(args.Authors) > 0 {
+		filters = append(filters, filter.Author(args.Authors...))
+	}
+	if len(args.ExcludeAuthors) > 0 {
+		filters = append(filters, filter.ExcludeAuthor(args.ExcludeAuthors...))
+	}
+	if args.TitleRegex != nil {
+		filters = append(filters, filter.TitleRegex(args.TitleRegex))
+	}
+	if len(args.Types) > 0 {
+		filters = append(filters, filter.Type(args.Types...))
+	}
+	return filter.All(filters...)
+}
+
+// Returns the comparator to sort items with for the given arguments, or nil to
+// keep them in rank order.
+func MakeComparator(args cli.Args) sorting.Comparator {
+	if args.Sort == nil {
+		return nil
+	}
+	return sorting.NewComparator(*args.Sort, args.Reverse)
+}
+
+// Returns whether any filters other than that of dead items were asked for.
+func isFiltering(args cli.Args) bool {
+	return args.MinScore > 0 || args.MinComments > 0 || args.MaxAge > 0 ||
+		len(args.Domains) > 0 || len(args.ExcludeDomains) > 0 ||
+		len(args.Authors) > 0 || len(args.ExcludeAuthors) > 0 ||
+		args.TitleRegex != nil || len(args.Types) > 0
+}
+
+// Returns the display options for the given arguments, detecting the output
+// width from the terminal if unset.
+func MakeOptions(args cli.Args) formatting.Options {
+	width := args.Width
+	if width == 0 {
+		width = formatting.TerminalWidth(os.Stdout)
+	}
+	return formatting.Options{
+		Clock:    &formatting.RealClock{},
+		Time:     args.Time,
+		Location: args.Location,
+		Locale:   args.Locale,
+		Width:    width,
+		Fields:   args.Fields,
+		Header:   args.CsvHeader,
+		Ansi:     formatting.IsTerminal(os.Stdout),
+	}
+}
+
+func DisplayCacheStats(stats api.CacheStats) {
+	fmt.Printf("directory:   %s\n", stats.Dir)
+	fmt.Printf("items:       %d (%s)\n", stats.Items, formatBytes(stats.ItemsBytes))
+	fmt.Printf("front pages: %d (%s)\n", stats.FrontPages, formatBytes(stats.FrontPagesBytes))
+}
+
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGT"[exp])
+}
+
+func isPartialFailure(err error) bool {
+	var fetchItemsErr *api.FetchItemsError
+	return errors.As(err, &fetchItemsErr)
+}
+
+// Exits on any error other than a partial failure, in which case the items
+// that could be fetched are still worth displaying.
+func exitIfFailed(err error) {
+	if err != nil && !isPartialFailure(err) {
+		fmt.Fprint(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
+
+// Reports a partial failure once the items that could be fetched have been
+// displayed.
+func exitIfPartiallyFailed(err error) {
+	if err != nil {
+		fmt.Fprint(os.Stderr, err.Error())
+		os.Exit(exitPartialFailure)
 	}
 }
 
@@ -67,24 +381,79 @@ func main() {
 		os.Exit(0)
 	}
 
-	if len(args.Query) != 0 {
-		searchItems, err := FetchSearchItems(api.SearchRequest{
-			Query:   args.Query,
-			Tags:    args.Tags,
-			Ranking: *args.RankingSearchResults,
-			Limit:   args.Limit,
-		})
-		if err != nil {
-			fmt.Fprint(os.Stderr, err.Error())
-			os.Exit(1)
+	ctx := context.Background()
+	if args.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, args.Timeout)
+		defer cancel()
+	}
+
+	client := MakeClient(!args.NoCache)
+	opts := MakeOptions(args)
+	formatter, err := formatting.NewFormatter(args.Style, opts)
+	exitIfFailed(err)
+	if len(args.Format) > 0 {
+		formatter, err = formatting.NewTemplateFormatter(args.Format, opts, formatter)
+		exitIfFailed(err)
+	}
+
+	// Filtered out items are made up for by fetching more of them.
+	keep := MakeFilter(args, time.Now())
+	order := MakeComparator(args)
+	idLimit := args.Limit
+	if isFiltering(args) {
+		idLimit = max(args.Limit, maxFilteredIds)
+	}
+
+	switch args.Command {
+	case cli.CacheClear:
+		cache, err := api.NewProdCache()
+		exitIfFailed(err)
+		exitIfFailed(cache.Clear())
+	case cli.CacheStats:
+		cache, err := api.NewProdCache()
+		exitIfFailed(err)
+		stats, err := cache.Stats()
+		exitIfFailed(err)
+		DisplayCacheStats(stats)
+	case cli.User:
+		user, submittedItems, err := FetchUser(ctx, &client, args.Username, args.Submissions, args.AllowPartial)
+		exitIfFailed(err)
+		if submittedItems != nil && !args.ShowDead {
+			submittedItems = RemoveDeadItems(submittedItems)
 		}
-		DisplayItems(searchItems, args.Style)
-	} else {
-		frontPageItems, err := FetchFrontPageItems(*args.RankingFrontPage, args.Limit)
-		if err != nil {
-			fmt.Fprint(os.Stderr, err.Error())
-			os.Exit(1)
+		DisplayUser(user, submittedItems, formatter)
+		exitIfPartiallyFailed(err)
+	case cli.Thread:
+		thread, err := FetchThread(ctx, &client, args.ThreadId, args.Depth, args.Limit)
+		exitIfFailed(err)
+		if !args.ShowDead {
+			RemoveDeadReplies(thread)
+		}
+		DisplayThread(thread, formatter)
+	case cli.Item:
+		err = FetchAndDisplayItems(ctx, &client, args.ItemIds, len(args.ItemIds), keep, order, args.AllowPartial, formatter)
+		exitIfFailed(err)
+		exitIfPartiallyFailed(err)
+	case cli.Search:
+		searchResponse, err := client.SearchContext(ctx, MakeSearchRequest(args, idLimit))
+		exitIfFailed(err)
+		ResolveCommentStories(ctx, &client, searchResponse.Results)
+		formatter = formatting.NewSearchResultsFormatter(formatter, searchResponse.Results)
+		if !args.Refetch {
+			// Search results have everything worth showing about items.
+			DisplayItems(SelectItems(SearchItems(searchResponse), args.Limit, keep, order), formatter)
+			break
 		}
-		DisplayItems(frontPageItems, args.Style)
+		err = FetchAndDisplayItems(ctx, &client, SearchItemIds(searchResponse), args.Limit, keep, order, args.AllowPartial, formatter)
+		exitIfFailed(err)
+		exitIfPartiallyFailed(err)
+	default:
+		frontPageItemIds, err := client.FetchFrontPageItemIdsContext(ctx, *args.RankingFrontPage, idLimit)
+		exitIfFailed(err)
+		err = FetchAndDisplayItems(ctx, &client, frontPageItemIds, args.Limit, keep, order, args.AllowPartial, formatter)
+		exitIfFailed(err)
+		exitIfPartiallyFailed(err)
 	}
+	exitIfFailed(formatting.FormatterErr(formatter))
 }
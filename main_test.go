@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/fmenozzi/hn/src/filter"
+	"github.com/fmenozzi/hn/src/formatting"
+	"github.com/stretchr/testify/assert"
+)
+
+// Serves item 1 as a story, and `null` for every other item as the HN API does
+// for unknown items.
+func WithOneKnownItem() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/item/1.json" {
+			fmt.Fprintln(w, `{ "id": 1, "type": "story", "title": "Story title", "url": "www.story.url" }`)
+			return
+		}
+		fmt.Fprintln(w, "null")
+	})
+}
+
+// Serves the given items, and `null` for every other item, counting the
+// requests for each path.
+func WithItemsCountingRequests(items map[string]string, requests map[string]int, mu *sync.Mutex) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		if item, ok := items[r.URL.Path]; ok {
+			fmt.Fprintln(w, item)
+			return
+		}
+		fmt.Fprintln(w, "null")
+	})
+}
+
+// Returns what fn writes to stdout.
+func captureStdout(t *testing.T, fn func()) string {
+	file, err := os.CreateTemp(t.TempDir(), "stdout")
+	assert.Nil(t, err)
+	defer file.Close()
+	stdout := os.Stdout
+	os.Stdout = file
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+
+	contents, err := os.ReadFile(file.Name())
+	assert.Nil(t, err)
+	return string(contents)
+}
+
+func TestFetchAndDisplayItemsFailsForUnknownItems(t *testing.T) {
+	server := httptest.NewServer(WithOneKnownItem())
+	defer server.Close()
+	client := api.NewHnClientBuilder().SetHnUrl(server.URL).Build()
+	formatter := formatting.NewPlainFormatter(formatting.Options{Clock: &formatting.RealClock{}})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = FetchAndDisplayItems(context.Background(), &client, []api.ItemId{1, 2000000000}, 2, filter.Live(), nil, false, formatter)
+	})
+
+	assert.ErrorContains(t, err, "no such item: 2000000000")
+	assert.Empty(t, output)
+}
+
+func TestFetchAndDisplayItemsShowsKnownItemsWithAllowPartial(t *testing.T) {
+	server := httptest.NewServer(WithOneKnownItem())
+	defer server.Close()
+	client := api.NewHnClientBuilder().SetHnUrl(server.URL).Build()
+	formatter := formatting.NewPlainFormatter(formatting.Options{Clock: &formatting.RealClock{}})
+
+	var err error
+	output := captureStdout(t, func() {
+		err = FetchAndDisplayItems(context.Background(), &client, []api.ItemId{1, 2000000000}, 2, filter.Live(), nil, true, formatter)
+	})
+
+	var fetchErr *api.FetchItemsError
+	assert.True(t, errors.As(err, &fetchErr))
+	assert.Equal(t, api.ItemId(2000000000), fetchErr.Failures[0].Id)
+	assert.Contains(t, output, "www.story.url\n")
+}
+
+func TestResolveCommentStoriesFetchesEachStoryOnce(t *testing.T) {
+	var mu sync.Mutex
+	requests := map[string]int{}
+	server := httptest.NewServer(WithItemsCountingRequests(map[string]string{
+		"/item/10.json": `{ "id": 10, "type": "story", "title": "Story title", "url": "www.story.url" }`,
+		"/item/20.json": `{ "id": 20, "type": "story", "title": "Other story title" }`,
+		"/item/21.json": `{ "id": 21, "type": "comment", "parent": 20 }`,
+	}, requests, &mu))
+	defer server.Close()
+	client := api.NewHnClientBuilder().SetHnUrl(server.URL).Build()
+	storyId := api.ItemId(10)
+	results := []api.SearchResult{
+		{Id: 11, StoryId: &storyId, Tags: []string{"comment"}},
+		{Id: 12, StoryId: &storyId, Tags: []string{"comment"}},
+		{Id: 21, Tags: []string{"comment"}},
+		{Id: 10, Tags: []string{"story"}},
+	}
+
+	ResolveCommentStories(context.Background(), &client, results)
+
+	assert.Equal(t, "Story title", *results[0].StoryTitle)
+	assert.Equal(t, "www.story.url", *results[1].StoryUrl)
+	assert.Equal(t, api.ItemId(20), *results[2].StoryId)
+	assert.Equal(t, "Other story title", *results[2].StoryTitle)
+	assert.Nil(t, results[3].StoryId)
+	assert.Equal(t, 1, requests["/item/10.json"])
+}
+
+func TestResolveCommentStoriesLeavesStoriesThatCannotBeFetched(t *testing.T) {
+	server := httptest.NewServer(WithOneKnownItem())
+	defer server.Close()
+	client := api.NewHnClientBuilder().SetHnUrl(server.URL).Build()
+	storyId := api.ItemId(99)
+	results := []api.SearchResult{
+		{Id: 11, StoryId: &storyId, Tags: []string{"comment"}},
+		{Id: 12, Tags: []string{"comment"}},
+	}
+
+	ResolveCommentStories(context.Background(), &client, results)
+
+	assert.Equal(t, api.ItemId(99), *results[0].StoryId)
+	assert.Nil(t, results[0].StoryTitle)
+	assert.Nil(t, results[1].StoryId)
+}
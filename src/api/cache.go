@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	itemsCacheDir     = "items"
+	frontPageCacheDir = "frontpage"
+)
+
+type CachePolicy struct {
+	// How long front page item id lists stay fresh.
+	FrontPageTtl time.Duration
+
+	// How long items younger than `OldItemAge` stay fresh.
+	YoungItemTtl time.Duration
+
+	// How long items older than `OldItemAge` stay fresh.
+	OldItemTtl time.Duration
+
+	// Age past which items rarely change anymore.
+	OldItemAge time.Duration
+}
+
+var prodCachePolicy = CachePolicy{
+	FrontPageTtl: 2 * time.Minute,
+	YoungItemTtl: 5 * time.Minute,
+	OldItemTtl:   30 * 24 * time.Hour,
+	OldItemAge:   3 * 24 * time.Hour,
+}
+
+// An on-disk cache of API responses. Caching is best-effort: entries that
+// cannot be read or written are treated as missing.
+type Cache struct {
+	dir    string
+	policy CachePolicy
+	now    func() time.Time
+}
+
+type CacheStats struct {
+	// The directory holding the cache.
+	Dir string
+
+	// Number of cached items and their total size in bytes.
+	Items      int
+	ItemsBytes int64
+
+	// Number of cached front page item id lists and their total size in bytes.
+	FrontPages      int
+	FrontPagesBytes int64
+}
+
+func NewCache(dir string, policy CachePolicy) *Cache {
+	return &Cache{
+		dir:    dir,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// Returns a cache under `$XDG_CACHE_HOME/hn`, or the platform's default cache
+// directory if that is not set.
+func NewProdCache() (*Cache, error) {
+	dir := os.Getenv("XDG_CACHE_HOME")
+	if len(dir) == 0 {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("cannot determine cache directory: %s\n", err.Error())
+		}
+		dir = userCacheDir
+	}
+	return NewCache(filepath.Join(dir, "hn"), prodCachePolicy), nil
+}
+
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+func (c *Cache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.dir}
+	var err error
+	stats.Items, stats.ItemsBytes, err = c.dirStats(itemsCacheDir)
+	if err != nil {
+		return CacheStats{}, err
+	}
+	stats.FrontPages, stats.FrontPagesBytes, err = c.dirStats(frontPageCacheDir)
+	if err != nil {
+		return CacheStats{}, err
+	}
+	return stats, nil
+}
+
+func (c *Cache) dirStats(subdir string) (int, int64, error) {
+	entries, err := os.ReadDir(filepath.Join(c.dir, subdir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	count := 0
+	var bytes int64
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		count++
+		bytes += info.Size()
+	}
+	return count, bytes, nil
+}
+
+func (c *Cache) loadItem(id ItemId) (*Item, bool) {
+	var item Item
+	modTime, ok := c.load(c.itemPath(id), &item)
+	if !ok {
+		return nil, false
+	}
+	ttl := c.policy.YoungItemTtl
+	if item.Time != nil && c.now().Sub(time.Unix(*item.Time, 0)) > c.policy.OldItemAge {
+		ttl = c.policy.OldItemTtl
+	}
+	if c.now().Sub(modTime) > ttl {
+		return nil, false
+	}
+	return &item, true
+}
+
+func (c *Cache) storeItem(item *Item) {
+	c.store(c.itemPath(item.Id), item)
+}
+
+func (c *Cache) loadFrontPage(endpoint string) ([]ItemId, bool) {
+	var ids []ItemId
+	modTime, ok := c.load(c.frontPagePath(endpoint), &ids)
+	if !ok || c.now().Sub(modTime) > c.policy.FrontPageTtl {
+		return nil, false
+	}
+	return ids, true
+}
+
+func (c *Cache) storeFrontPage(endpoint string, ids []ItemId) {
+	c.store(c.frontPagePath(endpoint), ids)
+}
+
+func (c *Cache) itemPath(id ItemId) string {
+	return filepath.Join(c.dir, itemsCacheDir, fmt.Sprintf("%d.json", id))
+}
+
+func (c *Cache) frontPagePath(endpoint string) string {
+	return filepath.Join(c.dir, frontPageCacheDir, fmt.Sprintf("%s.json", endpoint))
+}
+
+// Decodes the entry at the given path, returning when it was written.
+func (c *Cache) load(path string, v any) (time.Time, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return time.Time{}, false
+	}
+	if err := json.NewDecoder(file).Decode(v); err != nil {
+		return time.Time{}, false
+	}
+	return info.ModTime(), true
+}
+
+func (c *Cache) store(path string, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	// Write to a temporary file first so that concurrent readers never see a
+	// partially written entry.
+	file, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, writeErr := file.Write(data)
+	closeErr := file.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(file.Name(), path) != nil {
+		os.Remove(file.Name())
+	}
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testCachePolicy = CachePolicy{
+	FrontPageTtl: time.Minute,
+	YoungItemTtl: time.Hour,
+	OldItemTtl:   24 * time.Hour,
+	OldItemAge:   48 * time.Hour,
+}
+
+// Like `WithJsonResponse`, but counts the requests received.
+func WithCountedJsonResponse(json string) (*httptest.Server, *atomic.Int32) {
+	handler, requests := WithFailuresBeforeJsonResponse(0, 0, nil, json)
+	return httptest.NewServer(handler), requests
+}
+
+// Makes the cache entry at the given path look like it was written `age` ago.
+func ageCacheEntry(t *testing.T, cache *Cache, path string, age time.Duration) {
+	modTime := cache.now().Add(-age)
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestCachedFrontPageItemIdsAreReusedWhileFresh(t *testing.T) {
+	server, requests := WithCountedJsonResponse("[123, 456, 789]")
+	defer server.Close()
+	cache := NewCache(t.TempDir(), testCachePolicy)
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+
+	first, err := client.FetchFrontPageItemIds(Top, 10)
+	assert.Nil(t, err)
+	second, err := client.FetchFrontPageItemIds(Top, 2)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []ItemId{123, 456, 789}, first)
+	assert.Equal(t, []ItemId{123, 456}, second)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestCachedFrontPageItemIdsAreRefetchedOnceStale(t *testing.T) {
+	server, requests := WithCountedJsonResponse("[123, 456, 789]")
+	defer server.Close()
+	cache := NewCache(t.TempDir(), testCachePolicy)
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+
+	_, err := client.FetchFrontPageItemIds(Top, 10)
+	assert.Nil(t, err)
+	ageCacheEntry(t, cache, cache.frontPagePath("topstories"), 2*time.Minute)
+	_, err = client.FetchFrontPageItemIds(Top, 10)
+	assert.Nil(t, err)
+
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestCachedFrontPageItemIdsAreSeparatePerRanking(t *testing.T) {
+	server, requests := WithCountedJsonResponse("[123, 456, 789]")
+	defer server.Close()
+	cache := NewCache(t.TempDir(), testCachePolicy)
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+
+	_, err := client.FetchFrontPageItemIds(Top, 10)
+	assert.Nil(t, err)
+	_, err = client.FetchFrontPageItemIds(New, 10)
+	assert.Nil(t, err)
+
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestCachedItemsAreReusedWhileFresh(t *testing.T) {
+	server, requests := WithCountedJsonResponse(`{ "id": 123, "type": "story", "time": 1000 }`)
+	defer server.Close()
+	cache := NewCache(t.TempDir(), testCachePolicy)
+	cache.now = func() time.Time { return time.Unix(1000, 0) }
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+
+	first, err := client.FetchItem(123)
+	assert.Nil(t, err)
+	ageCacheEntry(t, cache, cache.itemPath(123), 30*time.Minute)
+	second, err := client.FetchItem(123)
+	assert.Nil(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestCachedYoungItemsAreRefetchedAfterYoungItemTtl(t *testing.T) {
+	server, requests := WithCountedJsonResponse(`{ "id": 123, "type": "story", "time": 1000 }`)
+	defer server.Close()
+	cache := NewCache(t.TempDir(), testCachePolicy)
+	cache.now = func() time.Time { return time.Unix(1000, 0) }
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+
+	_, err := client.FetchItem(123)
+	assert.Nil(t, err)
+	ageCacheEntry(t, cache, cache.itemPath(123), 2*time.Hour)
+	_, err = client.FetchItem(123)
+	assert.Nil(t, err)
+
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestCachedOldItemsAreReusedUntilOldItemTtl(t *testing.T) {
+	server, requests := WithCountedJsonResponse(`{ "id": 123, "type": "story", "time": 1000 }`)
+	defer server.Close()
+	cache := NewCache(t.TempDir(), testCachePolicy)
+	cache.now = func() time.Time { return time.Unix(1000, 0).Add(72 * time.Hour) }
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+
+	_, err := client.FetchItem(123)
+	assert.Nil(t, err)
+	ageCacheEntry(t, cache, cache.itemPath(123), 2*time.Hour)
+	_, err = client.FetchItem(123)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), requests.Load())
+
+	ageCacheEntry(t, cache, cache.itemPath(123), 25*time.Hour)
+	_, err = client.FetchItem(123)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestUnknownItemsAreNotCached(t *testing.T) {
+	server, requests := WithCountedJsonResponse("null")
+	defer server.Close()
+	cache := NewCache(t.TempDir(), testCachePolicy)
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+
+	_, err := client.FetchItem(123)
+	assert.ErrorContains(t, err, "no such item")
+	_, err = client.FetchItem(123)
+	assert.ErrorContains(t, err, "no such item")
+
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestCorruptCacheEntriesAreIgnored(t *testing.T) {
+	server, requests := WithCountedJsonResponse(`{ "id": 123, "type": "story" }`)
+	defer server.Close()
+	cache := NewCache(t.TempDir(), testCachePolicy)
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+	assert.Nil(t, os.MkdirAll(filepath.Dir(cache.itemPath(123)), 0o755))
+	assert.Nil(t, os.WriteFile(cache.itemPath(123), []byte("{"), 0o644))
+
+	item, err := client.FetchItem(123)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Item{Id: 123, Type: Story}, item)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestCacheStatsAndClear(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/topstories.json": "[123, 456]",
+		"/item/123.json":   `{ "id": 123, "type": "story" }`,
+		"/item/456.json":   `{ "id": 456, "type": "job" }`,
+	}))
+	defer server.Close()
+	dir := filepath.Join(t.TempDir(), "hn")
+	cache := NewCache(dir, testCachePolicy)
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetCache(cache).Build()
+
+	stats, err := cache.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, CacheStats{Dir: dir}, stats)
+
+	ids, err := client.FetchFrontPageItemIds(Top, 10)
+	assert.Nil(t, err)
+	_, err = client.FetchItems(ids)
+	assert.Nil(t, err)
+
+	stats, err = cache.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, stats.Items)
+	assert.Greater(t, stats.ItemsBytes, int64(0))
+	assert.Equal(t, 1, stats.FrontPages)
+	assert.Greater(t, stats.FrontPagesBytes, int64(0))
+
+	assert.Nil(t, cache.Clear())
+	stats, err = cache.Stats()
+	assert.Nil(t, err)
+	assert.Equal(t, CacheStats{Dir: dir}, stats)
+}
+
+func TestProdCacheHonorsXdgCacheHome(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+
+	cache, err := NewProdCache()
+
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(dir, "hn"), cache.dir)
+}
@@ -1,19 +1,29 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 const (
-	prodHnUrl               string = "https://hacker-news.firebaseio.com/v0/"
-	prodSearchPopularityUrl string = "http://hn.algolia.com/api/v1/search"
-	prodSearchDateUrl       string = "http://hn.algolia.com/api/v1/search_by_date"
-	maxStoriesLimit         int    = 500
+	prodHnUrl               string        = "https://hacker-news.firebaseio.com/v0/"
+	prodSearchPopularityUrl string        = "http://hn.algolia.com/api/v1/search"
+	prodSearchDateUrl       string        = "http://hn.algolia.com/api/v1/search_by_date"
+	maxStoriesLimit         int           = 500
+	maxSearchLimit          int           = 1000
+	maxSearchPageSize       int           = 500
+	prodMaxConcurrency      int           = 16
+	prodTimeout             time.Duration = 30 * time.Second
+
+	// Attributes of search results to get highlights and snippets of.
+	searchMatchedAttributes string = "title,url,story_text,comment_text"
 )
 
 type HnClient struct {
@@ -21,12 +31,20 @@ type HnClient struct {
 	hnUrl               string
 	searchPopularityUrl string
 	searchDateUrl       string
+	maxConcurrency      int
+	retryPolicy         RetryPolicy
+	sleep               func(context.Context, time.Duration) error
+	cache               *Cache
 }
 
 type HnClientBuilder interface {
 	SetHnUrl(string) HnClientBuilder
 	SetSearchPopularityUrl(string) HnClientBuilder
 	SetSearchDateUrl(string) HnClientBuilder
+	SetMaxConcurrency(int) HnClientBuilder
+	SetRetryPolicy(RetryPolicy) HnClientBuilder
+	SetTimeout(time.Duration) HnClientBuilder
+	SetCache(*Cache) HnClientBuilder
 	Build() HnClient
 }
 
@@ -49,23 +67,67 @@ func (b *concreteHnClientBuilder) SetSearchDateUrl(url string) HnClientBuilder {
 	return b
 }
 
+// Caps the number of requests `FetchItems` has in flight at once. Zero (the
+// default) means no cap, i.e. all items are requested at once.
+func (b *concreteHnClientBuilder) SetMaxConcurrency(max int) HnClientBuilder {
+	b.hnclient.maxConcurrency = max
+	return b
+}
+
+// Sets how failed requests are retried. The zero value (the default) never
+// retries.
+func (b *concreteHnClientBuilder) SetRetryPolicy(policy RetryPolicy) HnClientBuilder {
+	b.hnclient.retryPolicy = policy
+	return b
+}
+
+// Sets the time limit for each individual request, including any time spent
+// reading the response body. Zero (the default) means no time limit.
+func (b *concreteHnClientBuilder) SetTimeout(timeout time.Duration) HnClientBuilder {
+	b.hnclient.client.Timeout = timeout
+	return b
+}
+
+// Sets the cache used for front page item ids and items. Nil (the default)
+// means no caching.
+func (b *concreteHnClientBuilder) SetCache(cache *Cache) HnClientBuilder {
+	b.hnclient.cache = cache
+	return b
+}
+
 func (b *concreteHnClientBuilder) Build() HnClient {
 	return b.hnclient
 }
 
 func NewHnClientBuilder() HnClientBuilder {
-	return &concreteHnClientBuilder{}
+	return &concreteHnClientBuilder{
+		hnclient: HnClient{
+			sleep: sleepContext,
+		},
+	}
 }
 
-func MakeProdClient() HnClient {
+// Returns a builder preconfigured for the production APIs, for further
+// customization.
+func NewProdHnClientBuilder() HnClientBuilder {
 	return NewHnClientBuilder().
 		SetHnUrl(prodHnUrl).
 		SetSearchPopularityUrl(prodSearchPopularityUrl).
 		SetSearchDateUrl(prodSearchDateUrl).
-		Build()
+		SetMaxConcurrency(prodMaxConcurrency).
+		SetRetryPolicy(prodRetryPolicy).
+		SetTimeout(prodTimeout)
+}
+
+func MakeProdClient() HnClient {
+	return NewProdHnClientBuilder().Build()
 }
 
 func (hn *HnClient) FetchFrontPageItemIds(ranking FrontPageItemsRanking, limit int) ([]ItemId, error) {
+	return hn.FetchFrontPageItemIdsContext(context.Background(), ranking, limit)
+}
+
+func (hn *HnClient) FetchFrontPageItemIdsContext(ctx context.Context, ranking FrontPageItemsRanking, limit int) ([]ItemId, error) {
 	if limit < 0 || limit > maxStoriesLimit {
 		return nil, fmt.Errorf("invalid limit: %d\n", limit)
 	}
@@ -78,8 +140,32 @@ func (hn *HnClient) FetchFrontPageItemIds(ranking FrontPageItemsRanking, limit i
 		endpoint = "beststories"
 	case New:
 		endpoint = "newstories"
+	case Ask:
+		endpoint = "askstories"
+	case Show:
+		endpoint = "showstories"
+	case Jobs:
+		endpoint = "jobstories"
+	}
+	ids, err := hn.fetchFrontPage(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ids) <= limit {
+		return ids, nil
 	}
-	response, err := hn.client.Get(fmt.Sprintf("%s/%s.json", hn.hnUrl, endpoint))
+	return ids[:limit], nil
+}
+
+func (hn *HnClient) fetchFrontPage(ctx context.Context, endpoint string) ([]ItemId, error) {
+	if hn.cache != nil {
+		if ids, ok := hn.cache.loadFrontPage(endpoint); ok {
+			return ids, nil
+		}
+	}
+
+	response, err := hn.get(ctx, fmt.Sprintf("%s/%s.json", hn.hnUrl, endpoint))
 	if err != nil {
 		return nil, err
 	}
@@ -93,14 +179,24 @@ func (hn *HnClient) FetchFrontPageItemIds(ranking FrontPageItemsRanking, limit i
 		return nil, err
 	}
 
-	if len(ids) <= limit {
-		return ids, nil
+	if hn.cache != nil {
+		hn.cache.storeFrontPage(endpoint, ids)
 	}
-	return ids[:limit], nil
+	return ids, nil
 }
 
 func (hn *HnClient) FetchItem(id ItemId) (*Item, error) {
-	response, err := hn.client.Get(fmt.Sprintf("%s/item/%d.json", hn.hnUrl, id))
+	return hn.FetchItemContext(context.Background(), id)
+}
+
+func (hn *HnClient) FetchItemContext(ctx context.Context, id ItemId) (*Item, error) {
+	if hn.cache != nil {
+		if item, ok := hn.cache.loadItem(id); ok {
+			return item, nil
+		}
+	}
+
+	response, err := hn.get(ctx, fmt.Sprintf("%s/item/%d.json", hn.hnUrl, id))
 	if err != nil {
 		return nil, err
 	}
@@ -109,56 +205,381 @@ func (hn *HnClient) FetchItem(id ItemId) (*Item, error) {
 		return nil, fmt.Errorf("item fetch request failed with code %d\n", response.StatusCode)
 	}
 
-	var item Item
+	// The API responds with a literal `null` for unknown items.
+	var item *Item
 	if err := json.NewDecoder(response.Body).Decode(&item); err != nil {
 		return nil, err
 	}
+	if item == nil {
+		return nil, fmt.Errorf("no such item: %d\n", id)
+	}
 
-	return &item, nil
+	if hn.cache != nil && item.Id == id {
+		hn.cache.storeItem(item)
+	}
+	return item, nil
 }
 
 func (hn *HnClient) FetchItems(ids []ItemId) ([]Item, error) {
-	itemsMap := sync.Map{}
-	wg := sync.WaitGroup{}
+	return hn.FetchItemsContext(context.Background(), ids)
+}
+
+func (hn *HnClient) FetchItemsContext(ctx context.Context, ids []ItemId) ([]Item, error) {
+	items, _, err := hn.fetchItems(ctx, ids, hn.FetchItemContext, true, nil)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Like `FetchItems`, but a failure to fetch some of the items does not throw
+// away the rest. Returns the items that were fetched, in order, along with a
+// `*FetchItemsError` describing the ones that were not.
+func (hn *HnClient) FetchItemsPartial(ids []ItemId) ([]Item, error) {
+	return hn.FetchItemsPartialContext(context.Background(), ids)
+}
+
+func (hn *HnClient) FetchItemsPartialContext(ctx context.Context, ids []ItemId) ([]Item, error) {
+	items, errs, _ := hn.fetchItems(ctx, ids, hn.FetchItemContext, false, nil)
+	fetched := []Item{}
+	var failures []ItemFetchFailure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, ItemFetchFailure{Id: ids[i], Err: err})
+		} else {
+			fetched = append(fetched, items[i])
+		}
+	}
+	if len(failures) > 0 {
+		return fetched, &FetchItemsError{Requested: len(ids), Failures: failures}
+	}
+	return fetched, nil
+}
+
+// Like `FetchItems`, but calls fn with each item, in order, as soon as it and
+// all the items before it have been fetched, rather than once all of them have.
+// On failure, fn is not called again, but it may already have been called with
+// the items before the one that failed.
+func (hn *HnClient) StreamItems(ids []ItemId, fn func(Item)) error {
+	return hn.StreamItemsContext(context.Background(), ids, fn)
+}
+
+func (hn *HnClient) StreamItemsContext(ctx context.Context, ids []ItemId, fn func(Item)) error {
+	failed := false
+	_, _, err := hn.fetchItems(ctx, ids, hn.FetchItemContext, true, func(id ItemId, item *Item, err error) {
+		failed = failed || err != nil
+		if !failed {
+			fn(*item)
+		}
+	})
+	return err
+}
+
+// Like `StreamItems`, but a failure to fetch some of the items does not stop
+// the rest from being streamed. Returns a `*FetchItemsError` describing the
+// items that could not be fetched, if any.
+func (hn *HnClient) StreamItemsPartial(ids []ItemId, fn func(Item)) error {
+	return hn.StreamItemsPartialContext(context.Background(), ids, fn)
+}
+
+func (hn *HnClient) StreamItemsPartialContext(ctx context.Context, ids []ItemId, fn func(Item)) error {
+	var failures []ItemFetchFailure
+	hn.fetchItems(ctx, ids, hn.FetchItemContext, false, func(id ItemId, item *Item, err error) {
+		if err != nil {
+			failures = append(failures, ItemFetchFailure{Id: id, Err: err})
+		} else {
+			fn(*item)
+		}
+	})
+	if len(failures) > 0 {
+		return &FetchItemsError{Requested: len(ids), Failures: failures}
+	}
+	return nil
+}
+
+// Fetches the given items concurrently with fetch, returning the item or error
+// for each id in order, along with the first error to occur. If failFast is
+// set, that first error cancels all remaining fetches.
+//
+// If emit is set, it is called from the calling goroutine with each id and
+// either its item or its error, in order, as soon as the item and all the items
+// before it are done.
+func (hn *HnClient) fetchItems(ctx context.Context, ids []ItemId, fetch func(context.Context, ItemId) (*Item, error), failFast bool, emit func(ItemId, *Item, error)) ([]Item, []error, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := len(ids)
+	if hn.maxConcurrency > 0 && hn.maxConcurrency < workers {
+		workers = hn.maxConcurrency
+	}
+
+	items := make([]Item, len(ids))
+	errs := make([]error, len(ids))
+	indices := make(chan int)
+	done := make(chan int, len(ids))      // Buffered for non-blocking
 	errchan := make(chan error, len(ids)) // Buffered for non-blocking
-	for _, id := range ids {
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
 		wg.Add(1)
-		go func(id ItemId, errchan chan error) {
+		go func() {
 			defer wg.Done()
-			item, err := hn.FetchItem(id)
-			if err != nil {
-				errchan <- err
-			} else {
-				itemsMap.Store(id, *item)
+			for i := range indices {
+				item, err := fetch(ctx, ids[i])
+				if err != nil {
+					errs[i] = err
+					errchan <- err
+					if failFast {
+						cancel()
+					}
+				} else {
+					items[i] = *item
+				}
+				done <- i
 			}
-		}(id, errchan)
+		}()
+	}
+	handedOut := 0
+	go func() {
+	feed:
+		for ; handedOut < len(ids); handedOut++ {
+			select {
+			case indices <- handedOut:
+			case <-ctx.Done():
+				break feed
+			}
+		}
+		close(indices)
+		wg.Wait()
+		close(done)
+	}()
+
+	// Emit items in order as they are done, holding back those that finish
+	// before the ones ahead of them.
+	next := 0
+	finished := make([]bool, len(ids))
+	emitFinished := func() {
+		for ; next < len(ids) && finished[next]; next++ {
+			if emit != nil && errs[next] != nil {
+				emit(ids[next], nil, errs[next])
+			} else if emit != nil {
+				emit(ids[next], &items[next], nil)
+			}
+		}
+	}
+	for i := range done {
+		finished[i] = true
+		emitFinished()
 	}
-	wg.Wait()
 	close(errchan)
+
+	// Items that were never handed out failed because the context is done.
+	for i := handedOut; i < len(ids); i++ {
+		errs[i] = ctx.Err()
+		finished[i] = true
+	}
+	emitFinished()
 	err, errors := <-errchan
-	if errors {
-		// Return the first error we receive.
+	if !errors && handedOut < len(ids) {
+		err = ctx.Err()
+	}
+
+	return items, errs, err
+}
+
+func (hn *HnClient) FetchThread(id ItemId, options ThreadOptions) (*Thread, error) {
+	return hn.FetchThreadContext(context.Background(), id, options)
+}
+
+func (hn *HnClient) FetchThreadContext(ctx context.Context, id ItemId, options ThreadOptions) (*Thread, error) {
+	if options.MaxDepth < 0 {
+		return nil, fmt.Errorf("invalid max depth: %d\n", options.MaxDepth)
+	}
+	if options.MaxComments < 0 {
+		return nil, fmt.Errorf("invalid max comments: %d\n", options.MaxComments)
+	}
+
+	root, err := hn.FetchItemContext(ctx, id)
+	if err != nil {
 		return nil, err
 	}
+	thread := &Thread{Item: *root}
 
-	items := make([]Item, len(ids))
-	for i, id := range ids {
-		mapitem, ok := itemsMap.Load(id)
-		if !ok {
-			panic(fmt.Sprintf("no item %d in items map\n", id))
+	// Walk the tree one level at a time so that each level can be fetched
+	// concurrently, and so that comments closer to the root are preferred once
+	// the max comment cap is hit.
+	level := []*Thread{thread}
+	comments := 0
+	for depth := 1; len(level) > 0 && (options.MaxDepth == 0 || depth <= options.MaxDepth); depth++ {
+		var ids []ItemId
+		var parents []*Thread
+		for _, node := range level {
+			for _, kid := range node.Kids {
+				if options.MaxComments > 0 && comments == options.MaxComments {
+					break
+				}
+				ids = append(ids, kid)
+				parents = append(parents, node)
+				comments++
+			}
 		}
-		item := mapitem.(Item)
-		items[i] = item
+
+		items, err := hn.FetchItemsContext(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range items {
+			parents[i].Replies = append(parents[i].Replies, Thread{Item: item})
+		}
+
+		var nextLevel []*Thread
+		for _, node := range level {
+			for i := range node.Replies {
+				nextLevel = append(nextLevel, &node.Replies[i])
+			}
+		}
+		level = nextLevel
 	}
 
-	return items, nil
+	return thread, nil
+}
+
+func (hn *HnClient) FetchRootItem(id ItemId) (*Item, error) {
+	return hn.FetchRootItemContext(context.Background(), id)
+}
+
+// Fetches the item at the root of the thread that the given item is in, e.g.
+// the story that a comment is on, by walking up the item's parents. Poll
+// options are in the thread of their poll.
+func (hn *HnClient) FetchRootItemContext(ctx context.Context, id ItemId) (*Item, error) {
+	item, err := hn.FetchItemContext(ctx, id)
+	for err == nil {
+		parent := item.Parent
+		if parent == nil {
+			parent = item.Poll
+		}
+		if parent == nil {
+			return item, nil
+		}
+		item, err = hn.FetchItemContext(ctx, *parent)
+	}
+	return nil, err
+}
+
+// Like `FetchRootItem`, but for many items, which are walked up concurrently.
+// Returns the root of each item, in order, or nil if it could not be fetched,
+// along with a `*FetchItemsError` describing the items whose roots were not.
+func (hn *HnClient) FetchRootItemsPartial(ids []ItemId) ([]*Item, error) {
+	return hn.FetchRootItemsPartialContext(context.Background(), ids)
+}
+
+func (hn *HnClient) FetchRootItemsPartialContext(ctx context.Context, ids []ItemId) ([]*Item, error) {
+	items, errs, _ := hn.fetchItems(ctx, ids, hn.FetchRootItemContext, false, nil)
+	roots := make([]*Item, len(ids))
+	var failures []ItemFetchFailure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, ItemFetchFailure{Id: ids[i], Err: err})
+		} else {
+			roots[i] = &items[i]
+		}
+	}
+	if len(failures) > 0 {
+		return roots, &FetchItemsError{Requested: len(ids), Failures: failures}
+	}
+	return roots, nil
+}
+
+func (hn *HnClient) FetchUser(id string) (*User, error) {
+	return hn.FetchUserContext(context.Background(), id)
+}
+
+func (hn *HnClient) FetchUserContext(ctx context.Context, id string) (*User, error) {
+	response, err := hn.get(ctx, fmt.Sprintf("%s/user/%s.json", hn.hnUrl, url.PathEscape(id)))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode > 299 {
+		return nil, fmt.Errorf("user fetch request failed with code %d\n", response.StatusCode)
+	}
+
+	// The API responds with a literal `null` for unknown users.
+	var user *User
+	if err := json.NewDecoder(response.Body).Decode(&user); err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("no such user: %s\n", id)
+	}
+
+	return user, nil
 }
 
 func (hn *HnClient) Search(request SearchRequest) (*SearchResponse, error) {
-	if request.Limit < 0 || request.Limit > maxStoriesLimit {
+	return hn.SearchContext(context.Background(), request)
+}
+
+func (hn *HnClient) SearchContext(ctx context.Context, request SearchRequest) (*SearchResponse, error) {
+	if request.Limit < 0 || request.Limit > maxSearchLimit {
 		return nil, fmt.Errorf("invalid limit: %d\n", request.Limit)
 	}
+	if request.Page < 0 {
+		return nil, fmt.Errorf("invalid page: %d\n", request.Page)
+	}
+	filters := request.NumericFilters
+	if !request.Since.IsZero() {
+		filters = append(filters, NumericFilter{CreatedAt, ">=", request.Since.Unix()})
+	}
+	if !request.Until.IsZero() {
+		filters = append(filters, NumericFilter{CreatedAt, "<", request.Until.Unix()})
+	}
+	numericFilters := make([]string, len(filters))
+	for i, filter := range filters {
+		switch filter.Operator {
+		case "<", "<=", "=", ">=", ">":
+		default:
+			return nil, fmt.Errorf("invalid numeric filter: %s\n", filter)
+		}
+		numericFilters[i] = filter.String()
+	}
+
+	results := []SearchResult{}
+	if request.Limit == 0 {
+		return &SearchResponse{Results: results}, nil
+	}
 
+	// Results are fetched a page at a time until there are enough of them,
+	// starting from the page that the first of those asked for is on, which
+	// may also have some before it to skip.
+	pageSize := min(request.Limit, maxSearchPageSize)
+	offset := request.Page * request.Limit
+	skip := offset % pageSize
+	for page := offset / pageSize; len(results) < request.Limit; page++ {
+		hits, err := hn.searchPage(ctx, request, url.QueryEscape(strings.Join(numericFilters, ",")), page, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		wanted := hits[min(skip, len(hits)):]
+		skip = 0
+		for _, hit := range wanted[:min(len(wanted), request.Limit-len(results))] {
+			result, err := searchResultFromJson(hit)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, result)
+		}
+		if len(hits) < pageSize {
+			// There are no more results.
+			break
+		}
+	}
+	return &SearchResponse{
+		Results: results,
+	}, nil
+}
+
+// Fetches a page of hits, given the already escaped numeric filters.
+func (hn *HnClient) searchPage(ctx context.Context, request SearchRequest, numericFilters string, page int, pageSize int) ([]SearchResultJson, error) {
 	var endpoint string
 	switch request.Ranking {
 	case Popularity:
@@ -168,8 +589,11 @@ func (hn *HnClient) Search(request SearchRequest) (*SearchResponse, error) {
 	}
 	query := url.QueryEscape(request.Query)
 	tags := url.QueryEscape(request.Tags)
-	url := fmt.Sprintf("%s?query=%s&tags=%s&hitsPerPage=%d", endpoint, query, tags, request.Limit)
-	response, err := hn.client.Get(url)
+	url := fmt.Sprintf("%s?query=%s&tags=%s&hitsPerPage=%d&page=%d&attributesToHighlight=%s&attributesToSnippet=%s", endpoint, query, tags, pageSize, page, searchMatchedAttributes, searchMatchedAttributes)
+	if len(numericFilters) > 0 {
+		url += "&numericFilters=" + numericFilters
+	}
+	response, err := hn.get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -182,26 +606,60 @@ func (hn *HnClient) Search(request SearchRequest) (*SearchResponse, error) {
 	if err := json.NewDecoder(response.Body).Decode(&searchResponse); err != nil {
 		return nil, err
 	}
+	return searchResponse.Hits, nil
+}
 
-	var hits []SearchResultJson
-	if len(searchResponse.Hits) <= request.Limit {
-		hits = searchResponse.Hits
-	} else {
-		hits = searchResponse.Hits[:request.Limit]
+func searchResultFromJson(hit SearchResultJson) (SearchResult, error) {
+	id, err := strconv.Atoi(hit.Id)
+	if err != nil {
+		return SearchResult{}, err
 	}
-	results := make([]SearchResult, len(hits))
-	for i, hit := range hits {
-		id, err := strconv.Atoi(hit.Id)
-		if err != nil {
-			return nil, err
+	highlights := matchedValues(hit.HighlightResult)
+	return SearchResult{
+		Id:                         int32(id),
+		HighlightResultCommentText: highlights["comment_text"],
+		Title:                      hit.Title,
+		Url:                        nilIfEmpty(hit.Url),
+		Author:                     hit.Author,
+		Points:                     hit.Points,
+		NumComments:                hit.NumComments,
+		CreatedAt:                  hit.CreatedAt,
+		StoryId:                    hit.StoryId,
+		ParentId:                   hit.ParentId,
+		StoryText:                  nilIfEmpty(hit.StoryText),
+		CommentText:                nilIfEmpty(hit.CommentText),
+		StoryTitle:                 hit.StoryTitle,
+		StoryUrl:                   nilIfEmpty(hit.StoryUrl),
+		Tags:                       hit.Tags,
+		Highlights:                 highlights,
+		Snippets:                   matchedValues(hit.SnippetResult),
+	}, nil
+}
+
+// Returns the values of the highlighted or snippeted attributes by name,
+// skipping those that are not plain strings, or nil if there are none.
+func matchedValues(results map[string]json.RawMessage) map[string]string {
+	var values map[string]string
+	for name, raw := range results {
+		var result struct {
+			Value *string `json:"value"`
 		}
-		highlightResultCommentText := hit.HighlightResult.CommentText.Value
-		results[i] = SearchResult{
-			Id:                         int32(id),
-			HighlightResultCommentText: highlightResultCommentText,
+		if json.Unmarshal(raw, &result) != nil || result.Value == nil {
+			continue
 		}
+		if values == nil {
+			values = map[string]string{}
+		}
+		values[name] = *result.Value
 	}
-	return &SearchResponse{
-		Results: results,
-	}, nil
+	return values
+}
+
+// Algolia has empty strings where HN has no value at all, e.g. for the urls of
+// Ask HN stories.
+func nilIfEmpty(s *string) *string {
+	if s == nil || len(*s) == 0 {
+		return nil
+	}
+	return s
 }
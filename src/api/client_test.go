@@ -1,10 +1,16 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -27,12 +33,23 @@ func WithMultipleJsonResponses(responses map[string]string) *http.ServeMux {
 	return mux
 }
 
+func ptr[T any](t T) *T {
+	return &t
+}
+
 func WithFailedResponse(status int) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(status)
 	})
 }
 
+// Never responds, instead waiting until the client gives up on the request.
+func WithHangingResponse() http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+}
+
 func TestFetchFrontPageItemIdsSucceedsIfServerReturns200(t *testing.T) {
 	server := httptest.NewServer(WithJsonResponse("[123, 456, 789]"))
 	defer server.Close()
@@ -44,6 +61,33 @@ func TestFetchFrontPageItemIdsSucceedsIfServerReturns200(t *testing.T) {
 	assert.Equal(t, ids, []ItemId{123, 456, 789})
 }
 
+func TestFetchFrontPageItemIdsRequestsEndpointForRanking(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/topstories.json":  "[1]",
+		"/beststories.json": "[2]",
+		"/newstories.json":  "[3]",
+		"/askstories.json":  "[4]",
+		"/showstories.json": "[5]",
+		"/jobstories.json":  "[6]",
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	for ranking, expected := range map[FrontPageItemsRanking]ItemId{
+		Top:  1,
+		Best: 2,
+		New:  3,
+		Ask:  4,
+		Show: 5,
+		Jobs: 6,
+	} {
+		ids, err := client.FetchFrontPageItemIds(ranking, 10)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []ItemId{expected}, ids)
+	}
+}
+
 func TestFetchFrontPageItemIdsSucceedsWithLimitOfZero(t *testing.T) {
 	server := httptest.NewServer(WithJsonResponse("[123, 456, 789]"))
 	defer server.Close()
@@ -149,6 +193,58 @@ func TestFetchItemFailsIfJsonCannotBeParsed(t *testing.T) {
 	assert.ErrorContains(t, err, "unexpected EOF")
 }
 
+func TestFetchItemFailsIfItemDoesNotExist(t *testing.T) {
+	server := httptest.NewServer(WithJsonResponse("null"))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchItem(2000000000)
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "no such item: 2000000000")
+}
+
+func TestFetchItemsPartialReportsItemsThatDoNotExist(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/item/1.json":          `{ "id": 1, "type": "story" }`,
+		"/item/2000000000.json": "null",
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	items, err := client.FetchItemsPartial([]ItemId{1, 2000000000})
+
+	assert.Equal(t, []Item{{Id: 1, Type: Story}}, items)
+	var fetchErr *FetchItemsError
+	assert.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, ItemId(2000000000), fetchErr.Failures[0].Id)
+	assert.ErrorContains(t, fetchErr.Failures[0].Err, "no such item")
+}
+
+func TestFetchItemContextFailsIfContextTimesOut(t *testing.T) {
+	server := httptest.NewServer(WithHangingResponse())
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.FetchItemContext(ctx, 123)
+
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFetchItemFailsIfClientTimesOut(t *testing.T) {
+	server := httptest.NewServer(WithHangingResponse())
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetTimeout(10 * time.Millisecond).Build()
+
+	_, err := client.FetchItem(123)
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "Client.Timeout exceeded")
+}
+
 func TestFetchItemsSucceedsIfServerReturns200(t *testing.T) {
 	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
 		"/item/123.json": `{ "id": 123, "type": "story" }`,
@@ -177,6 +273,62 @@ func TestFetchItemsSucceedsIfServerReturns200(t *testing.T) {
 	})
 }
 
+func TestFetchItemsHonorsMaxConcurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			max := maxInFlight.Load()
+			if current <= max || maxInFlight.CompareAndSwap(max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintln(w, `{ "id": 123, "type": "story" }`)
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetMaxConcurrency(3).Build()
+
+	ids := make([]ItemId, 20)
+	for i := range ids {
+		ids[i] = 123
+	}
+	items, err := client.FetchItems(ids)
+
+	assert.Nil(t, err)
+	assert.Len(t, items, len(ids))
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(3))
+	assert.Greater(t, maxInFlight.Load(), int32(0))
+}
+
+func TestFetchItemsContextCancelsOutstandingRequestsOnFailure(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/item/123.json", WithHangingResponse())
+	mux.Handle("/item/456.json", WithFailedResponse(http.StatusNotFound))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchItemsContext(context.Background(), []ItemId{123, 456})
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "404") // not found
+}
+
+func TestFetchItemsContextFailsIfContextIsCancelled(t *testing.T) {
+	server := httptest.NewServer(WithHangingResponse())
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetMaxConcurrency(1).Build()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.FetchItemsContext(ctx, []ItemId{123, 456, 789})
+
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
 func TestFetchItemsFailsIfServerReturns500(t *testing.T) {
 	server := httptest.NewServer(WithFailedResponse(http.StatusInternalServerError))
 	defer server.Close()
@@ -199,6 +351,407 @@ func TestFetchItemsFailsIfJsonCannotBeParsed(t *testing.T) {
 	assert.ErrorContains(t, err, "unexpected EOF")
 }
 
+func TestStreamItemsStreamsItemsInOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Earlier items take longer, so that they finish last.
+		var id int
+		fmt.Sscanf(r.URL.Path, "/item/%d.json", &id)
+		time.Sleep(time.Duration(10-id) * 5 * time.Millisecond)
+		fmt.Fprintf(w, `{ "id": %d, "type": "story" }`, id)
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	var streamed []ItemId
+	err := client.StreamItems([]ItemId{1, 2, 3, 4, 5}, func(item Item) {
+		streamed = append(streamed, item.Id)
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []ItemId{1, 2, 3, 4, 5}, streamed)
+}
+
+func TestStreamItemsStreamsItemsBeforeAllAreFetched(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/item/1.json", WithJsonResponse(`{ "id": 1, "type": "story" }`))
+	mux.Handle("/item/2.json", WithHangingResponse())
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var streamed []ItemId
+	err := client.StreamItemsContext(ctx, []ItemId{1, 2}, func(item Item) {
+		streamed = append(streamed, item.Id)
+		cancel() // Item 2 would otherwise never arrive.
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []ItemId{1}, streamed)
+}
+
+func TestStreamItemsStopsStreamingOnFailure(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/item/1.json": `{ "id": 1, "type": "story" }`,
+		"/item/3.json": `{ "id": 3, "type": "story" }`,
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetMaxConcurrency(1).Build()
+
+	var streamed []ItemId
+	err := client.StreamItems([]ItemId{1, 2, 3}, func(item Item) {
+		streamed = append(streamed, item.Id)
+	})
+
+	assert.ErrorContains(t, err, "404") // not found
+	assert.Equal(t, []ItemId{1}, streamed)
+}
+
+func TestStreamItemsPartialStreamsItemsThatCouldBeFetched(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/item/1.json": `{ "id": 1, "type": "story" }`,
+		"/item/3.json": `{ "id": 3, "type": "story" }`,
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	var streamed []ItemId
+	err := client.StreamItemsPartial([]ItemId{1, 2, 3}, func(item Item) {
+		streamed = append(streamed, item.Id)
+	})
+
+	var fetchItemsErr *FetchItemsError
+	assert.ErrorAs(t, err, &fetchItemsErr)
+	assert.Equal(t, 3, fetchItemsErr.Requested)
+	assert.Len(t, fetchItemsErr.Failures, 1)
+	assert.Equal(t, ItemId(2), fetchItemsErr.Failures[0].Id)
+	assert.Equal(t, []ItemId{1, 3}, streamed)
+}
+
+func WithThreadResponses() *http.ServeMux {
+	// 1
+	// ├── 2
+	// │   └── 4
+	// │       └── 5
+	// └── 3
+	return WithMultipleJsonResponses(map[string]string{
+		"/item/1.json": `{ "id": 1, "type": "story", "kids": [2, 3] }`,
+		"/item/2.json": `{ "id": 2, "type": "comment", "kids": [4] }`,
+		"/item/3.json": `{ "id": 3, "type": "comment" }`,
+		"/item/4.json": `{ "id": 4, "type": "comment", "kids": [5] }`,
+		"/item/5.json": `{ "id": 5, "type": "comment" }`,
+	})
+}
+
+func TestFetchItemsPartialSucceedsIfServerReturns200(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/item/123.json": `{ "id": 123, "type": "story" }`,
+		"/item/456.json": `{ "id": 456, "type": "job" }`,
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	items, err := client.FetchItemsPartial([]ItemId{123, 456})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []Item{
+		{Id: 123, Type: Story},
+		{Id: 456, Type: Job},
+	}, items)
+}
+
+func TestFetchItemsPartialReturnsFetchedItemsAndFailures(t *testing.T) {
+	mux := WithMultipleJsonResponses(map[string]string{
+		"/item/123.json": `{ "id": 123, "type": "story" }`,
+		"/item/789.json": `{ "id": 789, "type": "poll" }`,
+		"/item/999.json": `{`,
+	})
+	mux.Handle("/item/456.json", WithFailedResponse(http.StatusInternalServerError))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	items, err := client.FetchItemsPartial([]ItemId{123, 456, 789, 999})
+
+	assert.Equal(t, []Item{
+		{Id: 123, Type: Story},
+		{Id: 789, Type: Poll},
+	}, items)
+	var fetchItemsErr *FetchItemsError
+	assert.ErrorAs(t, err, &fetchItemsErr)
+	assert.Equal(t, 4, fetchItemsErr.Requested)
+	assert.Len(t, fetchItemsErr.Failures, 2)
+	assert.Equal(t, ItemId(456), fetchItemsErr.Failures[0].Id)
+	assert.ErrorContains(t, fetchItemsErr.Failures[0].Err, "500") // internal server error
+	assert.Equal(t, ItemId(999), fetchItemsErr.Failures[1].Id)
+	assert.ErrorContains(t, fetchItemsErr.Failures[1].Err, "unexpected EOF")
+	assert.Equal(t, "failed to fetch 2 of 4 items:\n"+
+		"    item 456: item fetch request failed with code 500\n"+
+		"    item 999: unexpected EOF\n", err.Error())
+}
+
+func TestFetchItemsPartialContextReportsUnfetchedItemsIfContextIsCancelled(t *testing.T) {
+	server := httptest.NewServer(WithJsonResponse(`{ "id": 123, "type": "story" }`))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	items, err := client.FetchItemsPartialContext(ctx, []ItemId{123, 456})
+
+	assert.Empty(t, items)
+	assert.ErrorIs(t, err, context.Canceled)
+	var fetchItemsErr *FetchItemsError
+	assert.ErrorAs(t, err, &fetchItemsErr)
+	assert.Len(t, fetchItemsErr.Failures, 2)
+}
+
+func TestFetchThreadSucceedsIfServerReturns200(t *testing.T) {
+	server := httptest.NewServer(WithThreadResponses())
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	thread, err := client.FetchThread(1, ThreadOptions{MaxComments: 10})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Thread{
+		Item: Item{Id: 1, Type: Story, Kids: []ItemId{2, 3}},
+		Replies: []Thread{
+			{
+				Item: Item{Id: 2, Type: Comment, Kids: []ItemId{4}},
+				Replies: []Thread{
+					{
+						Item: Item{Id: 4, Type: Comment, Kids: []ItemId{5}},
+						Replies: []Thread{
+							{Item: Item{Id: 5, Type: Comment}},
+						},
+					},
+				},
+			},
+			{Item: Item{Id: 3, Type: Comment}},
+		},
+	}, thread)
+}
+
+func TestFetchThreadStopsAtMaxDepth(t *testing.T) {
+	server := httptest.NewServer(WithThreadResponses())
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	thread, err := client.FetchThread(1, ThreadOptions{MaxDepth: 1, MaxComments: 10})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Thread{
+		Item: Item{Id: 1, Type: Story, Kids: []ItemId{2, 3}},
+		Replies: []Thread{
+			{Item: Item{Id: 2, Type: Comment, Kids: []ItemId{4}}},
+			{Item: Item{Id: 3, Type: Comment}},
+		},
+	}, thread)
+}
+
+func TestFetchThreadStopsAtMaxComments(t *testing.T) {
+	server := httptest.NewServer(WithThreadResponses())
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	thread, err := client.FetchThread(1, ThreadOptions{MaxComments: 3})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Thread{
+		Item: Item{Id: 1, Type: Story, Kids: []ItemId{2, 3}},
+		Replies: []Thread{
+			{
+				Item: Item{Id: 2, Type: Comment, Kids: []ItemId{4}},
+				Replies: []Thread{
+					{Item: Item{Id: 4, Type: Comment, Kids: []ItemId{5}}},
+				},
+			},
+			{Item: Item{Id: 3, Type: Comment}},
+		},
+	}, thread)
+}
+
+func TestFetchThreadFetchesAllCommentsWithoutMaxComments(t *testing.T) {
+	server := httptest.NewServer(WithThreadResponses())
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	thread, err := client.FetchThread(1, ThreadOptions{})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Thread{
+		Item: Item{Id: 1, Type: Story, Kids: []ItemId{2, 3}},
+		Replies: []Thread{
+			{
+				Item: Item{Id: 2, Type: Comment, Kids: []ItemId{4}},
+				Replies: []Thread{
+					{
+						Item: Item{Id: 4, Type: Comment, Kids: []ItemId{5}},
+						Replies: []Thread{
+							{Item: Item{Id: 5, Type: Comment}},
+						},
+					},
+				},
+			},
+			{Item: Item{Id: 3, Type: Comment}},
+		},
+	}, thread)
+}
+
+func TestFetchThreadFailsWithInvalidOptions(t *testing.T) {
+	server := httptest.NewServer(WithThreadResponses())
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchThread(1, ThreadOptions{MaxDepth: -1})
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "invalid max depth")
+
+	_, err = client.FetchThread(1, ThreadOptions{MaxComments: -1})
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "invalid max comments")
+}
+
+func TestFetchThreadFailsIfRootDoesNotExist(t *testing.T) {
+	server := httptest.NewServer(WithJsonResponse("null"))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchThread(2000000000, ThreadOptions{MaxComments: 10})
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "no such item: 2000000000")
+}
+
+func TestFetchThreadFailsIfServerReturns500(t *testing.T) {
+	server := httptest.NewServer(WithFailedResponse(http.StatusInternalServerError))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchThread(1, ThreadOptions{MaxComments: 10})
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "500") // internal server error
+}
+
+func TestFetchRootItemWalksUpParents(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/item/1.json": `{ "id": 1, "type": "story", "kids": [2] }`,
+		"/item/2.json": `{ "id": 2, "type": "comment", "parent": 1, "kids": [3] }`,
+		"/item/3.json": `{ "id": 3, "type": "comment", "parent": 2 }`,
+		"/item/4.json": `{ "id": 4, "type": "poll", "parts": [5] }`,
+		"/item/5.json": `{ "id": 5, "type": "pollopt", "poll": 4 }`,
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	root, err := client.FetchRootItem(3)
+	assert.Nil(t, err)
+	assert.Equal(t, ItemId(1), root.Id)
+
+	root, err = client.FetchRootItem(1)
+	assert.Nil(t, err)
+	assert.Equal(t, ItemId(1), root.Id)
+
+	root, err = client.FetchRootItem(5)
+	assert.Nil(t, err)
+	assert.Equal(t, ItemId(4), root.Id)
+}
+
+func TestFetchRootItemFailsIfServerReturns500(t *testing.T) {
+	server := httptest.NewServer(WithFailedResponse(http.StatusInternalServerError))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchRootItem(3)
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "500") // internal server error
+}
+
+func TestFetchRootItemsPartialReportsItemsWhoseRootsCannotBeFetched(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/item/1.json": `{ "id": 1, "type": "story", "kids": [2] }`,
+		"/item/2.json": `{ "id": 2, "type": "comment", "parent": 1 }`,
+		"/item/3.json": `{ "id": 3, "type": "comment", "parent": 4 }`,
+		"/item/4.json": "null",
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	roots, err := client.FetchRootItemsPartial([]ItemId{2, 3, 1})
+
+	assert.Equal(t, []*Item{{Id: 1, Type: Story, Kids: []ItemId{2}}, nil, {Id: 1, Type: Story, Kids: []ItemId{2}}}, roots)
+	var fetchErr *FetchItemsError
+	assert.ErrorAs(t, err, &fetchErr)
+	assert.Equal(t, 3, fetchErr.Requested)
+	assert.Equal(t, ItemId(3), fetchErr.Failures[0].Id)
+	assert.ErrorContains(t, fetchErr.Failures[0].Err, "no such item: 4")
+}
+
+func TestFetchUserSucceedsIfServerReturns200(t *testing.T) {
+	server := httptest.NewServer(WithMultipleJsonResponses(map[string]string{
+		"/user/username.json": `
+		{
+			"id": "username",
+			"created": 1173923446,
+			"karma": 2937,
+			"about": "About text",
+			"submitted": [123, 456, 789]
+		}
+		`,
+	}))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	user, err := client.FetchUser("username")
+
+	about := "About text"
+	assert.Nil(t, err)
+	assert.Equal(t, &User{
+		Id:        "username",
+		Created:   1173923446,
+		Karma:     2937,
+		About:     &about,
+		Submitted: []ItemId{123, 456, 789},
+	}, user)
+}
+
+func TestFetchUserFailsIfUserDoesNotExist(t *testing.T) {
+	server := httptest.NewServer(WithJsonResponse("null"))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchUser("username")
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "no such user: username")
+}
+
+func TestFetchUserFailsIfServerReturns500(t *testing.T) {
+	server := httptest.NewServer(WithFailedResponse(http.StatusInternalServerError))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchUser("username")
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "500") // internal server error
+}
+
+func TestFetchUserFailsIfJsonCannotBeParsed(t *testing.T) {
+	server := httptest.NewServer(WithJsonResponse("{"))
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchUser("username")
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "unexpected EOF")
+}
+
 func TestSearchSucceedsIfServerReturns200(t *testing.T) {
 	server := httptest.NewServer(WithJsonResponse(`
 	{
@@ -333,6 +886,24 @@ func TestSearchSucceedsWithALimitLessThanResponseSize(t *testing.T) {
 	}}, response)
 }
 
+func TestSearchContextFailsIfContextIsCancelled(t *testing.T) {
+	server := httptest.NewServer(WithHangingResponse())
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchPopularityUrl(server.URL).Build()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := client.SearchContext(ctx, SearchRequest{
+		Query:   "query", // unimportant
+		Tags:    "story",
+		Ranking: Popularity,
+		Limit:   30,
+	})
+
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func TestSearchFailsWhenServerReturns500(t *testing.T) {
 	server := httptest.NewServer(WithFailedResponse(http.StatusInternalServerError))
 	defer server.Close()
@@ -358,7 +929,7 @@ func TestSearchFailsWithInvalidLimits(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.ErrorContains(t, err, "invalid limit")
 
-	_, err = client.Search(SearchRequest{Limit: maxStoriesLimit + 1})
+	_, err = client.Search(SearchRequest{Limit: maxSearchLimit + 1})
 	assert.NotNil(t, err)
 	assert.ErrorContains(t, err, "invalid limit")
 }
@@ -378,3 +949,288 @@ func TestSearchFailsIfJsonCannotBeParsed(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.ErrorContains(t, err, "unexpected EOF")
 }
+
+// Responds with pages of hits with consecutive ids starting at 1, out of total
+// hits, and records the query of each request.
+func WithSearchPages(total int, queries *[]url.Values) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		*queries = append(*queries, query)
+		page, _ := strconv.Atoi(query.Get("page"))
+		hitsPerPage, _ := strconv.Atoi(query.Get("hitsPerPage"))
+		var hits []string
+		for id := page*hitsPerPage + 1; id <= min((page+1)*hitsPerPage, total); id++ {
+			hits = append(hits, fmt.Sprintf(`{ "objectID": "%d" }`, id))
+		}
+		fmt.Fprintf(w, `{ "hits": [%s] }`, strings.Join(hits, ","))
+	})
+}
+
+func searchResultIds(response *SearchResponse) []ItemId {
+	ids := []ItemId{}
+	for _, result := range response.Results {
+		ids = append(ids, result.Id)
+	}
+	return ids
+}
+
+func TestSearchPagesThroughResultsBeyondOnePage(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(WithSearchPages(2000, &queries))
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchPopularityUrl(server.URL).Build()
+
+	response, err := client.Search(SearchRequest{
+		Query:   "query", // unimportant
+		Tags:    "story",
+		Ranking: Popularity,
+		Limit:   700,
+	})
+
+	assert.Nil(t, err)
+	assert.Len(t, response.Results, 700)
+	assert.Equal(t, ItemId(1), response.Results[0].Id)
+	assert.Equal(t, ItemId(700), response.Results[699].Id)
+	assert.Len(t, queries, 2)
+	assert.Equal(t, "0", queries[0].Get("page"))
+	assert.Equal(t, "1", queries[1].Get("page"))
+	assert.Equal(t, "500", queries[1].Get("hitsPerPage"))
+}
+
+func TestSearchStopsPagingWhenResultsRunOut(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(WithSearchPages(600, &queries))
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchPopularityUrl(server.URL).Build()
+
+	response, err := client.Search(SearchRequest{Ranking: Popularity, Limit: 1000})
+
+	assert.Nil(t, err)
+	assert.Len(t, response.Results, 600)
+	assert.Len(t, queries, 2)
+}
+
+func TestSearchStartsAtPage(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(WithSearchPages(2000, &queries))
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchPopularityUrl(server.URL).Build()
+
+	response, err := client.Search(SearchRequest{Ranking: Popularity, Limit: 30, Page: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, ItemId(61), response.Results[0].Id)
+	assert.Equal(t, ItemId(90), response.Results[29].Id)
+
+	// Pages of more results than are fetched at a time still line up.
+	response, err = client.Search(SearchRequest{Ranking: Popularity, Limit: 600, Page: 1})
+	assert.Nil(t, err)
+	assert.Len(t, response.Results, 600)
+	assert.Equal(t, ItemId(601), response.Results[0].Id)
+	assert.Equal(t, ItemId(1200), response.Results[599].Id)
+}
+
+func TestSearchStartsAtPageWithoutShrinkingPages(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(WithSearchPages(2000, &queries))
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchPopularityUrl(server.URL).Build()
+
+	response, err := client.Search(SearchRequest{Ranking: Popularity, Limit: 997, Page: 1})
+
+	assert.Nil(t, err)
+	assert.Len(t, response.Results, 997)
+	assert.Equal(t, ItemId(998), response.Results[0].Id)
+	assert.Equal(t, ItemId(1994), response.Results[996].Id)
+	assert.Len(t, queries, 3)
+	for _, query := range queries {
+		assert.Equal(t, "500", query.Get("hitsPerPage"))
+	}
+}
+
+func TestSearchSendsNumericFilters(t *testing.T) {
+	var queries []url.Values
+	server := httptest.NewServer(WithSearchPages(0, &queries))
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchDateUrl(server.URL).Build()
+
+	_, err := client.Search(SearchRequest{
+		Query:   "query", // unimportant
+		Tags:    "story",
+		Ranking: Date,
+		Limit:   30,
+		NumericFilters: []NumericFilter{
+			{Points, ">", 50},
+			{NumComments, ">=", 10},
+		},
+		Since: time.Unix(1700000000, 0),
+		Until: time.Unix(1800000000, 0),
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, "points>50,num_comments>=10,created_at_i>=1700000000,created_at_i<1800000000", queries[0].Get("numericFilters"))
+	assert.Equal(t, "title,url,story_text,comment_text", queries[0].Get("attributesToHighlight"))
+	assert.Equal(t, "title,url,story_text,comment_text", queries[0].Get("attributesToSnippet"))
+}
+
+func TestSearchFailsWithInvalidPagesAndNumericFilters(t *testing.T) {
+	server := httptest.NewServer(WithJsonResponse("[]")) // unimportant
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchPopularityUrl(server.URL).Build()
+
+	_, err := client.Search(SearchRequest{Limit: 30, Page: -1})
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "invalid page")
+
+	_, err = client.Search(SearchRequest{Limit: 30, NumericFilters: []NumericFilter{{Points, "!=", 1}}})
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "invalid numeric filter: points!=1")
+}
+
+func TestSearchDecodesFullHits(t *testing.T) {
+	server := httptest.NewServer(WithJsonResponse(`
+	{
+		"hits": [
+			{
+				"objectID": "123",
+				"title": "Story title",
+				"url": "https://story.url",
+				"author": "storyuser",
+				"points": 10,
+				"num_comments": 20,
+				"created_at_i": 1700000000,
+				"story_id": 123,
+				"parent_id": null,
+				"story_text": "",
+				"comment_text": null,
+				"_tags": ["story", "author_storyuser", "story_123"],
+				"_highlightResult": {
+					"title": { "value": "Story <em>title</em>", "matchLevel": "full", "matchedWords": ["title"] },
+					"author": { "value": "storyuser", "matchLevel": "none", "matchedWords": [] }
+				},
+				"_snippetResult": {
+					"title": { "value": "… <em>title</em>", "matchLevel": "full" }
+				}
+			},
+			{
+				"objectID": "456",
+				"author": "commentuser",
+				"created_at_i": 1700000100,
+				"story_id": 123,
+				"parent_id": 123,
+				"comment_text": "Comment <em>text</em>",
+				"story_title": "Story title",
+				"story_url": "https://story.url",
+				"_tags": ["comment", "author_commentuser", "story_123"],
+				"_highlightResult": {
+					"comment_text": { "value": "Comment <em>text</em>", "matchLevel": "full" },
+					"matched_list": [{ "value": "ignored" }]
+				}
+			}
+		]
+	}
+	`))
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchPopularityUrl(server.URL).Build()
+
+	response, err := client.Search(SearchRequest{
+		Query:   "title", // unimportant
+		Tags:    "(story,comment)",
+		Ranking: Popularity,
+		Limit:   30,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &SearchResponse{Results: []SearchResult{
+		{
+			Id:          123,
+			Title:       ptr("Story title"),
+			Url:         ptr("https://story.url"),
+			Author:      ptr("storyuser"),
+			Points:      ptr(int32(10)),
+			NumComments: ptr(int32(20)),
+			CreatedAt:   ptr(int64(1700000000)),
+			StoryId:     ptr(ItemId(123)),
+			Tags:        []string{"story", "author_storyuser", "story_123"},
+			Highlights:  map[string]string{"title": "Story <em>title</em>", "author": "storyuser"},
+			Snippets:    map[string]string{"title": "… <em>title</em>"},
+		},
+		{
+			Id:                         456,
+			HighlightResultCommentText: "Comment <em>text</em>",
+			Author:                     ptr("commentuser"),
+			CreatedAt:                  ptr(int64(1700000100)),
+			StoryId:                    ptr(ItemId(123)),
+			ParentId:                   ptr(ItemId(123)),
+			CommentText:                ptr("Comment <em>text</em>"),
+			StoryTitle:                 ptr("Story title"),
+			StoryUrl:                   ptr("https://story.url"),
+			Tags:                       []string{"comment", "author_commentuser", "story_123"},
+			Highlights:                 map[string]string{"comment_text": "Comment <em>text</em>"},
+		},
+	}}, response)
+}
+
+func TestSearchResultToItem(t *testing.T) {
+	story := SearchResult{
+		Id:          123,
+		Title:       ptr("Story title"),
+		Url:         ptr("https://story.url"),
+		Author:      ptr("storyuser"),
+		Points:      ptr(int32(10)),
+		NumComments: ptr(int32(20)),
+		CreatedAt:   ptr(int64(1700000000)),
+		StoryId:     ptr(ItemId(123)),
+		StoryText:   ptr("Story text"),
+		Tags:        []string{"story", "author_storyuser", "story_123"},
+	}
+	comment := SearchResult{
+		Id:          456,
+		Author:      ptr("commentuser"),
+		CreatedAt:   ptr(int64(1700000100)),
+		StoryId:     ptr(ItemId(123)),
+		ParentId:    ptr(ItemId(123)),
+		CommentText: ptr("Comment text"),
+		StoryTitle:  ptr("Story title"),
+		Tags:        []string{"comment", "author_commentuser", "story_123"},
+	}
+
+	assert.Equal(t, Item{
+		Id:          123,
+		Type:        Story,
+		By:          ptr("storyuser"),
+		Time:        ptr(int64(1700000000)),
+		Text:        ptr("Story text"),
+		Url:         ptr("https://story.url"),
+		Score:       ptr(int32(10)),
+		Title:       ptr("Story title"),
+		Descendants: ptr(int32(20)),
+	}, story.ToItem())
+	assert.Equal(t, Item{
+		Id:     456,
+		Type:   Comment,
+		By:     ptr("commentuser"),
+		Time:   ptr(int64(1700000100)),
+		Text:   ptr("Comment text"),
+		Parent: ptr(ItemId(123)),
+	}, comment.ToItem())
+}
+
+func TestParseItemId(t *testing.T) {
+	for _, s := range []string{
+		"8863",
+		" 8863\n",
+		"https://news.ycombinator.com/item?id=8863",
+		"http://www.news.ycombinator.com/item?id=8863#8864",
+		"news.ycombinator.com/item?id=8863&p=2",
+	} {
+		id, err := ParseItemId(s)
+		assert.Nil(t, err, s)
+		assert.Equal(t, ItemId(8863), id, s)
+	}
+
+	for _, s := range []string{"", "0", "-1", "abc", "https://news.ycombinator.com/user?id=pg", "https://example.com/item?id=8863"} {
+		_, err := ParseItemId(s)
+		assert.NotNil(t, err, s)
+		assert.ErrorContains(t, err, "invalid item id", s)
+	}
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+type RetryPolicy struct {
+	// Max number of attempts per request, including the first one. Values
+	// less than one are treated as one, i.e. no retries.
+	MaxAttempts int
+
+	// Delay before the first retry, doubling on each subsequent retry. Half of
+	// each delay is randomized to avoid retrying in lockstep.
+	BaseDelay time.Duration
+
+	// Upper bound on the delay between attempts, including delays requested
+	// by the server via `Retry-After`. Zero means no bound.
+	MaxDelay time.Duration
+
+	// Response status codes that warrant a retry. Transport errors are always
+	// retried.
+	RetryableStatusCodes []int
+}
+
+var prodRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	RetryableStatusCodes: []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	},
+}
+
+// Performs a GET request, retrying transient failures according to the
+// client's retry policy. Once the attempts run out, the last response or
+// error is returned as is for the caller to handle.
+func (hn *HnClient) get(ctx context.Context, url string) (*http.Response, error) {
+	policy := hn.retryPolicy
+	for attempt := 1; ; attempt++ {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		response, err := hn.client.Do(request)
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || !policy.shouldRetry(response, err) {
+			return response, err
+		}
+		delay := policy.delay(attempt, response)
+		if response != nil {
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		if err := hn.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Waits for the given duration, returning early if the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (p *RetryPolicy) shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return slices.Contains(p.RetryableStatusCodes, response.StatusCode)
+}
+
+// Returns how long to wait after the given (1-based) failed attempt.
+func (p *RetryPolicy) delay(attempt int, response *http.Response) time.Duration {
+	delay, ok := retryAfter(response)
+	if !ok {
+		backoff := p.BaseDelay << (attempt - 1)
+		delay = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// Parses the `Retry-After` header, which holds either a number of seconds or
+// an HTTP date.
+func retryAfter(response *http.Response) (time.Duration, bool) {
+	if response == nil {
+		return 0, false
+	}
+	header := response.Header.Get("Retry-After")
+	if len(header) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:          3,
+	BaseDelay:            100 * time.Millisecond,
+	RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+}
+
+// Fails the first `failures` requests with the given status before responding
+// with the given json. The returned counter tracks the total number of
+// requests received.
+func WithFailuresBeforeJsonResponse(failures int32, status int, header http.Header, json string) (http.HandlerFunc, *atomic.Int32) {
+	var requests atomic.Int32
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprintln(w, json)
+	}), &requests
+}
+
+func WithRecordedSleeps(client *HnClient) *[]time.Duration {
+	var sleeps []time.Duration
+	client.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return &sleeps
+}
+
+func TestFetchFrontPageItemIdsSucceedsAfterRetries(t *testing.T) {
+	handler, requests := WithFailuresBeforeJsonResponse(2, http.StatusServiceUnavailable, nil, "[123, 456, 789]")
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(testRetryPolicy).Build()
+	WithRecordedSleeps(&client)
+
+	ids, err := client.FetchFrontPageItemIds(Top, 10)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []ItemId{123, 456, 789}, ids)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestFetchItemSucceedsAfterRetries(t *testing.T) {
+	handler, requests := WithFailuresBeforeJsonResponse(2, http.StatusServiceUnavailable, nil, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(testRetryPolicy).Build()
+	WithRecordedSleeps(&client)
+
+	item, err := client.FetchItem(123)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &Item{Id: 123, Type: Story}, item)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestSearchSucceedsAfterRetries(t *testing.T) {
+	handler, requests := WithFailuresBeforeJsonResponse(2, http.StatusServiceUnavailable, nil, `{ "hits": [{ "objectID": "123" }] }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewHnClientBuilder().SetSearchPopularityUrl(server.URL).SetRetryPolicy(testRetryPolicy).Build()
+	WithRecordedSleeps(&client)
+
+	response, err := client.Search(SearchRequest{
+		Query:   "query", // unimportant
+		Tags:    "story",
+		Ranking: Popularity,
+		Limit:   30,
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, &SearchResponse{Results: []SearchResult{{Id: 123}}}, response)
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestFetchItemFailsOnceRetriesAreExhausted(t *testing.T) {
+	handler, requests := WithFailuresBeforeJsonResponse(3, http.StatusServiceUnavailable, nil, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(testRetryPolicy).Build()
+	WithRecordedSleeps(&client)
+
+	_, err := client.FetchItem(123)
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "503") // service unavailable
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func TestFetchItemDoesNotRetryNonRetryableStatusCodes(t *testing.T) {
+	handler, requests := WithFailuresBeforeJsonResponse(1, http.StatusNotFound, nil, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(testRetryPolicy).Build()
+	sleeps := WithRecordedSleeps(&client)
+
+	_, err := client.FetchItem(123)
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "404") // not found
+	assert.Equal(t, int32(1), requests.Load())
+	assert.Empty(t, *sleeps)
+}
+
+func TestFetchItemDoesNotRetryWithoutRetryPolicy(t *testing.T) {
+	handler, requests := WithFailuresBeforeJsonResponse(1, http.StatusServiceUnavailable, nil, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).Build()
+
+	_, err := client.FetchItem(123)
+
+	assert.NotNil(t, err)
+	assert.ErrorContains(t, err, "503") // service unavailable
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestRetriesBackOffExponentiallyWithJitter(t *testing.T) {
+	handler, _ := WithFailuresBeforeJsonResponse(3, http.StatusServiceUnavailable, nil, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	policy := testRetryPolicy
+	policy.MaxAttempts = 4
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(policy).Build()
+	sleeps := WithRecordedSleeps(&client)
+
+	_, err := client.FetchItem(123)
+
+	assert.Nil(t, err)
+	assert.Len(t, *sleeps, 3)
+	for i, sleep := range *sleeps {
+		backoff := policy.BaseDelay << i
+		assert.GreaterOrEqual(t, sleep, backoff/2)
+		assert.LessOrEqual(t, sleep, backoff)
+	}
+}
+
+func TestRetriesAreCappedAtMaxDelay(t *testing.T) {
+	handler, _ := WithFailuresBeforeJsonResponse(2, http.StatusServiceUnavailable, nil, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	policy := testRetryPolicy
+	policy.BaseDelay = time.Hour
+	policy.MaxDelay = time.Second
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(policy).Build()
+	sleeps := WithRecordedSleeps(&client)
+
+	_, err := client.FetchItem(123)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, *sleeps)
+}
+
+func TestRetriesHonorRetryAfterSeconds(t *testing.T) {
+	header := http.Header{"Retry-After": []string{"7"}}
+	handler, _ := WithFailuresBeforeJsonResponse(1, http.StatusServiceUnavailable, header, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(testRetryPolicy).Build()
+	sleeps := WithRecordedSleeps(&client)
+
+	_, err := client.FetchItem(123)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{7 * time.Second}, *sleeps)
+}
+
+func TestRetriesHonorRetryAfterDate(t *testing.T) {
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	header := http.Header{"Retry-After": []string{date}}
+	handler, _ := WithFailuresBeforeJsonResponse(1, http.StatusServiceUnavailable, header, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(testRetryPolicy).Build()
+	sleeps := WithRecordedSleeps(&client)
+
+	_, err := client.FetchItem(123)
+
+	assert.Nil(t, err)
+	assert.Len(t, *sleeps, 1)
+	assert.Greater(t, (*sleeps)[0], 50*time.Second)
+	assert.LessOrEqual(t, (*sleeps)[0], time.Minute)
+}
+
+func TestRetriesTransportErrors(t *testing.T) {
+	server := httptest.NewServer(WithJsonResponse("[]")) // unimportant
+	server.Close()
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(testRetryPolicy).Build()
+	sleeps := WithRecordedSleeps(&client)
+
+	_, err := client.FetchItem(123)
+
+	assert.NotNil(t, err)
+	assert.Len(t, *sleeps, 2)
+}
+
+func TestRetriesStopIfContextIsDone(t *testing.T) {
+	handler, requests := WithFailuresBeforeJsonResponse(1, http.StatusServiceUnavailable, nil, `{ "id": 123, "type": "story" }`)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	policy := testRetryPolicy
+	policy.BaseDelay = time.Hour
+	client := NewHnClientBuilder().SetHnUrl(server.URL).SetRetryPolicy(policy).Build()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.FetchItemContext(ctx, 123)
+
+	assert.NotNil(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), requests.Load())
+}
@@ -1,7 +1,36 @@
 package api
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type ItemId = int32
 
+// Host of the HN site, whose item pages link to items by id.
+const hnHost = "news.ycombinator.com"
+
+// Parses an item id, given either as is, e.g. "8863", or as the url of the
+// item's page on HN, e.g. "https://news.ycombinator.com/item?id=8863".
+func ParseItemId(s string) (ItemId, error) {
+	text := strings.TrimSpace(s)
+	if strings.HasPrefix(text, hnHost) || strings.HasPrefix(text, "www."+hnHost) {
+		text = "https://" + text
+	}
+	if parsed, err := url.Parse(text); err == nil && strings.TrimPrefix(parsed.Hostname(), "www.") == hnHost && parsed.Path == "/item" {
+		text = parsed.Query().Get("id")
+	}
+	id, err := strconv.ParseInt(text, 10, 32)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid item id: %s\n", s)
+	}
+	return ItemId(id), nil
+}
+
 type ItemType string
 
 const (
@@ -59,12 +88,75 @@ type Item struct {
 	Descendants *int32 `json:"descendants"`
 }
 
+func (item *Item) IsDeleted() bool {
+	return item.Deleted != nil && *item.Deleted
+}
+
+func (item *Item) IsDead() bool {
+	return item.Dead != nil && *item.Dead
+}
+
+type Thread struct {
+	// The item at the root of the thread.
+	Item
+
+	// The threads rooted at the item's comments, in ranked display order.
+	Replies []Thread `json:"replies"`
+}
+
+type ThreadOptions struct {
+	// Max depth of comments to fetch below the root item. Zero means no limit.
+	MaxDepth int
+
+	// Max number of comments to fetch across the whole thread. Zero means no
+	// limit.
+	MaxComments int
+}
+
+type ItemFetchFailure struct {
+	// The id of the item that could not be fetched.
+	Id ItemId
+
+	// Why the item could not be fetched.
+	Err error
+}
+
+// Returned alongside the successfully fetched items when only some of the
+// requested items could be fetched.
+type FetchItemsError struct {
+	// The number of items that were requested.
+	Requested int
+
+	// The items that could not be fetched, in the order they were requested.
+	Failures []ItemFetchFailure
+}
+
+func (e *FetchItemsError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to fetch %d of %d items:\n", len(e.Failures), e.Requested)
+	for _, failure := range e.Failures {
+		fmt.Fprintf(&b, "    item %d: %s\n", failure.Id, strings.TrimSpace(failure.Err.Error()))
+	}
+	return b.String()
+}
+
+func (e *FetchItemsError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure.Err
+	}
+	return errs
+}
+
 type FrontPageItemsRanking int
 
 const (
 	Top FrontPageItemsRanking = iota
 	Best
 	New
+	Ask
+	Show
+	Jobs
 )
 
 func (r FrontPageItemsRanking) ToPointer() *FrontPageItemsRanking {
@@ -87,11 +179,121 @@ type SearchRequest struct {
 	Tags    string
 	Ranking SearchItemsRanking
 	Limit   int
+
+	// Conditions on numeric attributes that results must all satisfy.
+	NumericFilters []NumericFilter
+
+	// Zero-based page of results to start at, where each page holds Limit
+	// results.
+	Page int
+
+	// If set, only results created at or after this time.
+	Since time.Time
+
+	// If set, only results created before this time.
+	Until time.Time
+}
+
+// Numeric attributes of search results that can be filtered on.
+type NumericAttribute string
+
+const (
+	// Creation date in Unix time.
+	CreatedAt NumericAttribute = "created_at_i"
+
+	// The story's score.
+	Points NumericAttribute = "points"
+
+	// The total comment count.
+	NumComments NumericAttribute = "num_comments"
+)
+
+// A condition on a numeric attribute of search results, e.g. points > 50.
+type NumericFilter struct {
+	Attribute NumericAttribute
+
+	// One of <, <=, =, >=, >.
+	Operator string
+
+	Value int64
+}
+
+func (f NumericFilter) String() string {
+	return fmt.Sprintf("%s%s%d", f.Attribute, f.Operator, f.Value)
 }
 
 type SearchResult struct {
 	Id                         ItemId
 	HighlightResultCommentText string
+
+	// The title of the story, poll, or job.
+	Title *string
+
+	// The url of the story.
+	Url *string
+
+	// The username of the item's author.
+	Author *string
+
+	// The story's score.
+	Points *int32
+
+	// In the case of stories or polls, the total comment count.
+	NumComments *int32
+
+	// Creation date of the item in Unix time.
+	CreatedAt *int64
+
+	// The story that the comment is on.
+	StoryId *ItemId
+
+	// The comment's parent: either another comment or the relevant story.
+	ParentId *ItemId
+
+	// The story text in HTML.
+	StoryText *string
+
+	// The comment text in HTML.
+	CommentText *string
+
+	// The title and url of the story that the comment is on.
+	StoryTitle *string
+	StoryUrl   *string
+
+	// Tags of the item, e.g. its type, "author_<username>", or "story_<id>".
+	Tags []string
+
+	// Attributes with the matched terms of the query emphasized with <em> tags,
+	// and snippets of them around the matched terms, by attribute name.
+	Highlights map[string]string
+	Snippets   map[string]string
+}
+
+// Returns the item as found by search. Unlike items fetched from HN, it lacks
+// the ids of the item's comments and poll options, and is never deleted or
+// dead.
+func (r *SearchResult) ToItem() Item {
+	item := Item{
+		Id:          r.Id,
+		By:          r.Author,
+		Time:        r.CreatedAt,
+		Text:        r.StoryText,
+		Parent:      r.ParentId,
+		Url:         r.Url,
+		Score:       r.Points,
+		Title:       r.Title,
+		Descendants: r.NumComments,
+	}
+	if r.CommentText != nil {
+		item.Text = r.CommentText
+	}
+	for _, tag := range r.Tags {
+		switch tag := ItemType(tag); tag {
+		case Job, Story, Comment, Poll, PollOpt:
+			item.Type = tag
+		}
+	}
+	return item
 }
 
 type SearchResponse struct {
@@ -99,14 +301,41 @@ type SearchResponse struct {
 }
 
 type SearchResultJson struct {
-	Id              string `json:"objectID"`
-	HighlightResult struct {
-		CommentText struct {
-			Value string `json:"value"`
-		} `json:"comment_text"`
-	} `json:"_highlightResult"`
+	Id              string                     `json:"objectID"`
+	Title           *string                    `json:"title"`
+	Url             *string                    `json:"url"`
+	Author          *string                    `json:"author"`
+	Points          *int32                     `json:"points"`
+	NumComments     *int32                     `json:"num_comments"`
+	CreatedAt       *int64                     `json:"created_at_i"`
+	StoryId         *ItemId                    `json:"story_id"`
+	ParentId        *ItemId                    `json:"parent_id"`
+	StoryText       *string                    `json:"story_text"`
+	CommentText     *string                    `json:"comment_text"`
+	StoryTitle      *string                    `json:"story_title"`
+	StoryUrl        *string                    `json:"story_url"`
+	Tags            []string                   `json:"_tags"`
+	HighlightResult map[string]json.RawMessage `json:"_highlightResult"`
+	SnippetResult   map[string]json.RawMessage `json:"_snippetResult"`
 }
 
 type SearchResponseJson struct {
 	Hits []SearchResultJson `json:"hits"`
 }
+
+type User struct {
+	// The user's unique username. Case-sensitive.
+	Id string `json:"id"`
+
+	// Creation date of the user in Unix time.
+	Created int64 `json:"created"`
+
+	// The user's karma.
+	Karma int32 `json:"karma"`
+
+	// The user's optional self-description in HTML.
+	About *string `json:"about"`
+
+	// List of the user's stories, polls and comments, most recent first.
+	Submitted []ItemId `json:"submitted"`
+}
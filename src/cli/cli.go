@@ -1,45 +1,175 @@
 package cli
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/fmenozzi/hn/src/api"
+	"github.com/fmenozzi/hn/src/filter"
 	"github.com/fmenozzi/hn/src/formatting"
+	"github.com/fmenozzi/hn/src/sorting"
 )
 
 const (
 	Version = "0.1.0"
 	usage   = `A simple commandline hacker news client.
 
+Usage:
+    hn [options]
+    hn user <username> [options]
+    hn thread <id> [options]
+    hn item [<id>...] [options]
+    hn cache clear|stats
+
+Commands:
+    user <username>   show the profile of the given user
+    thread <id>       show the given item along with its comment tree, with
+                      --limit capping the total number of comments, 0 for no
+                      limit
+    item [<id>...]    show the given items, or those whose ids are read one per
+                      line from stdin if none are given
+    cache clear       delete all cached items
+    cache stats       show the location and size of the cache
+
 Options:
-    -h, --help      show this help message and exit
-    -v, --version   show program version information and exit
-    -l, --limit     max number of results to fetch (default: 30)
-    -s, --style     output style, one of plain, markdown, md, json, csv (default: plain)
-    -r, --ranking   ranking method
-                        one of top, new, best for front page items (default: top)
-                        one of date, popularity for search result items (default: popularity)
-    -q, --query     search query
-    -t, --tags      filter search results on specific tags (default: story)
+    -h, --help        show this help message and exit
+    -v, --version     show program version information and exit
+    -l, --limit       max number of results to show (default: 30)
+    -s, --style       output style, one of plain, markdown, md, json, jsonl, csv, tsv, rss, atom, html (default: plain)
+    -r, --ranking     ranking method
+                          one of top, new, best, ask, show, jobs for front page items (default: top)
+                          one of date, popularity for search result items (default: popularity)
+    -q, --query       search query
+    -t, --tags        filter search results on specific tags (default: story)
+    --comments        search comments rather than stories, showing the story each is on
+    --since           only search results created at or after this time, e.g. 2024-01-31, or 48h for the last 48 hours
+    --until           only search results created before this time, in the same formats as --since
+    --min-points      only search results with at least this many points (default: 0)
+    --refetch         fetch search results from the HN API rather than showing them as found by search
+    --submissions     number of the user's most recent submissions to list (default: 0)
+    --depth           max depth of comments to show in a thread, 0 for no limit (default: 0)
+    --timeout         max time to wait for results, e.g. 10s or 1m, 0 for no limit (default: 0)
+    --allow-partial   show the items that could be fetched even if others could not
+    --no-cache        neither read from nor write to the on-disk cache
+    --show-dead       show deleted and dead items instead of omitting them
+    --width           max width of plain output lines, 0 to detect from the terminal (default: 0)
+    --format          go text/template to write each item with instead of --style
+    --format-file     file to read the --format template from
+    --fields          comma-separated item fields to output in csv, tsv, json and jsonl styles
+    --csv-header      start csv and tsv output with a header row of field names
+    --time            how to show times, one of relative, iso, local, unix (default: relative)
+    --tz              time zone of iso and local times, e.g. Europe/Rome (default: UTC for iso, system for local)
+    --locale          language of relative times, one of de, en, es, fr, it (default: en)
+    --min-score       only show items with at least this many points (default: 0)
+    --min-comments    only show items with at least this many comments (default: 0)
+    --max-age         only show items at most this old, e.g. 6h, 0 for no limit (default: 0)
+    --domain          only show items linking to these comma-separated domains or their subdomains
+    --exclude-domain  omit items linking to these comma-separated domains or their subdomains
+    --author          only show items by these comma-separated users
+    --exclude-author  omit items by these comma-separated users
+    --title-regex     only show items whose title matches this regular expression
+    --type            only show items of these comma-separated types, e.g. story,poll
+    --sort            order to show items in, one of score, comments, time, title (default: rank)
+    --reverse         show items in the reverse of the --sort order
 
 Notes:
     The csv output columns (and json field names) are:
 
-    id,deleted,type,by,time,text,dead,parent,poll,kids,url,score,title,parts,descendents
+    id,deleted,type,by,time,text,dead,parent,poll,kids,url,score,title,parts,descendants
+
+    See https://github.com/HackerNews/API for schema details. The derived fields
+    post_url, domain and iso_time can also be picked with --fields. The tsv style
+    escapes tabs, newlines and backslashes within fields as \t, \n and \\.
+
+    With --submissions, json output nests the submissions under the user's
+    profile as a "submissions" array. Users and items do not share columns, so
+    --submissions is invalid with the csv and tsv styles.
 
-    See https://github.com/HackerNews/API for schema details.
+    Comments found by search are shown along with the story they are on, in
+    every style but csv and tsv, whose columns are the same for every item. The
+    story's story_id, story_title and story_url can be picked with --fields in
+    any style, and are added to json and jsonl output by default. --comments is
+    the same as --tags comment, and is ANDed with any other --tags.
+
+    Search results are fetched a page at a time, so --limit can be up to 1000
+    for search rather than 500. --since and --until also take times in RFC 3339
+    format, e.g. 2024-01-31T09:00:00Z. Dates are midnight in --tz, if given, or
+    in the system time zone.
+
+    Search results are shown as found by search, which lacks the ids of their
+    comments and poll options. Use --refetch to show them as on HN instead.
+
+    In plain and markdown output, the terms that search results matched are in
+    bold, and a snippet of where they matched is shown under each result. Plain
+    output is only in bold when written to a terminal.
 
     Search tags are ANDed by default but can be ORed if between parentheses. For
     example, "author_pg,(story,poll)" filters on "author_pg AND (type=story OR type=poll)".
     See https://hn.algolia.com/api for more.
+
+    Items are cached under $XDG_CACHE_HOME/hn, for longer the older they are.
+
+    Templates are executed against each item's fields as in the json output,
+    e.g. '{{.Id}} {{deref .Title}}'. Pointer fields can be dereferenced with
+    deref, and the helpers ago, time (as per --time), postUrl, userUrl, text
+    (html to plain text) and truncate <width> are also available. User profiles
+    are written in --style, which templated submissions cannot be mixed into,
+    so --format is invalid with --submissions. Items the template fails on are
+    left out, and the first failure is reported once the rest are written.
+
+    Item ids can also be given as the urls of their pages on HN, e.g.
+    https://news.ycombinator.com/item?id=8863. The item command shows every item
+    it is given that passes the filters, regardless of --limit.
+
+    Filters apply to front page, search and item results, with --limit counting
+    only the items they keep. More items are fetched as needed to make up for
+    those filtered out, up to the first 500, or --limit if that is more. Sorting
+    then reorders the items shown, with those missing the field sorted by last,
+    and leaves ties in rank order.
+
+    With --allow-partial, items that could not be fetched are reported on stderr
+    and hn exits with status 3.
 `
 )
 
+type Command int
+
+const (
+	// List the front page items.
+	FrontPage Command = iota
+
+	// Search items via the Algolia API.
+	Search
+
+	// Show a user's profile.
+	User
+
+	// Show an item's comment tree.
+	Thread
+
+	// Show the given items.
+	Item
+
+	// Delete the on-disk cache.
+	CacheClear
+
+	// Show statistics about the on-disk cache.
+	CacheStats
+)
+
 type Args struct {
 	// If true, version information was requested.
 	Version bool
 
+	// The command to run.
+	Command Command
+
 	// Ranking method for front page items.
 	RankingFrontPage *api.FrontPageItemsRanking
 
@@ -57,6 +187,135 @@ type Args struct {
 
 	// Comma-separated list of tags for filtering search results.
 	Tags string
+
+	// If set, only search results created at or after this time.
+	Since time.Time
+
+	// If set, only search results created before this time.
+	Until time.Time
+
+	// Min points of search results.
+	MinPoints int
+
+	// If true, fetch search results from the HN API.
+	Refetch bool
+
+	// Username of the user whose profile to show.
+	Username string
+
+	// Number of the user's most recent submissions to list.
+	Submissions int
+
+	// Id of the item at the root of the thread to show.
+	ThreadId api.ItemId
+
+	// Ids of the items to show, in order.
+	ItemIds []api.ItemId
+
+	// Max depth of comments to show in a thread.
+	Depth int
+
+	// Max time to wait for results. Zero means no limit.
+	Timeout time.Duration
+
+	// If true, show the items that could be fetched even if others could not.
+	AllowPartial bool
+
+	// If true, bypass the on-disk cache.
+	NoCache bool
+
+	// If true, show deleted and dead items.
+	ShowDead bool
+
+	// Max width of plain output lines. Zero means detect from the terminal.
+	Width int
+
+	// Template to write each item with instead of the style, if any.
+	Format string
+
+	// Item fields to output in csv, tsv and json styles. Empty means all.
+	Fields []string
+
+	// If true, start csv and tsv output with a header row.
+	CsvHeader bool
+
+	// How to show times.
+	Time formatting.TimeFormat
+
+	// Time zone of absolute times, if given.
+	Location *time.Location
+
+	// Language of relative times.
+	Locale *formatting.Locale
+
+	// Min score of items to show.
+	MinScore int
+
+	// Min number of comments of items to show.
+	MinComments int
+
+	// Max age of items to show. Zero means no limit.
+	MaxAge time.Duration
+
+	// If any, only show items linking to these domains.
+	Domains []string
+
+	// Omit items linking to these domains.
+	ExcludeDomains []string
+
+	// If any, only show items by these users.
+	Authors []string
+
+	// Omit items by these users.
+	ExcludeAuthors []string
+
+	// If set, only show items whose title matches.
+	TitleRegex *regexp.Regexp
+
+	// If any, only show items of these types.
+	Types []api.ItemType
+
+	// What to sort items by, if anything.
+	Sort *sorting.Key
+
+	// If true, sort items in reverse.
+	Reverse bool
+}
+
+// Reads item ids, or the urls of items, one per line, skipping blank lines.
+func readItemIds(r io.Reader) ([]api.ItemId, error) {
+	var ids []api.ItemId
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		id, err := api.ParseItemId(scanner.Text())
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading item ids: %s\n", err.Error())
+	}
+	return ids, nil
+}
+
+// Parses the commandline flags, allowing them to be interspersed with
+// positional arguments, and returns the positional arguments in order.
+func parseFlags() []string {
+	var positional []string
+	args := os.Args[1:]
+	for {
+		flag.CommandLine.Parse(args)
+		args = flag.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
 }
 
 func ArgsFromCli() (Args, error) {
@@ -66,6 +325,36 @@ func ArgsFromCli() (Args, error) {
 	var ranking string
 	var query string
 	var tags string
+	var sincestr string
+	var untilstr string
+	var minPoints int
+	var refetch bool
+	var comments bool
+	var submissions int
+	var depth int
+	var timeout time.Duration
+	var allowPartial bool
+	var noCache bool
+	var showDead bool
+	var width int
+	var format string
+	var formatFile string
+	var fieldsstr string
+	var csvHeader bool
+	var timestr string
+	var tz string
+	var localestr string
+	var minScore int
+	var minComments int
+	var maxAge time.Duration
+	var domains string
+	var excludeDomains string
+	var authors string
+	var excludeAuthors string
+	var titleRegexstr string
+	var typesstr string
+	var sortstr string
+	var reverse bool
 
 	flag.Usage = func() { fmt.Print(usage) }
 	flag.BoolVar(&version, "v", false, "")
@@ -80,8 +369,124 @@ func ArgsFromCli() (Args, error) {
 	flag.StringVar(&query, "query", "", "")
 	flag.StringVar(&tags, "t", "", "")
 	flag.StringVar(&tags, "tags", "", "")
+	flag.StringVar(&sincestr, "since", "", "")
+	flag.StringVar(&untilstr, "until", "", "")
+	flag.IntVar(&minPoints, "min-points", 0, "")
+	flag.BoolVar(&refetch, "refetch", false, "")
+	flag.BoolVar(&comments, "comments", false, "")
+	flag.IntVar(&submissions, "submissions", 0, "")
+	flag.IntVar(&depth, "depth", 0, "")
+	flag.DurationVar(&timeout, "timeout", 0, "")
+	flag.BoolVar(&allowPartial, "allow-partial", false, "")
+	flag.BoolVar(&noCache, "no-cache", false, "")
+	flag.BoolVar(&showDead, "show-dead", false, "")
+	flag.IntVar(&width, "width", 0, "")
+	flag.StringVar(&format, "format", "", "")
+	flag.StringVar(&formatFile, "format-file", "", "")
+	flag.StringVar(&fieldsstr, "fields", "", "")
+	flag.BoolVar(&csvHeader, "csv-header", false, "")
+	flag.StringVar(&timestr, "time", "relative", "")
+	flag.StringVar(&tz, "tz", "", "")
+	flag.StringVar(&localestr, "locale", "en", "")
+	flag.IntVar(&minScore, "min-score", 0, "")
+	flag.IntVar(&minComments, "min-comments", 0, "")
+	flag.DurationVar(&maxAge, "max-age", 0, "")
+	flag.StringVar(&domains, "domain", "", "")
+	flag.StringVar(&excludeDomains, "exclude-domain", "", "")
+	flag.StringVar(&authors, "author", "", "")
+	flag.StringVar(&excludeAuthors, "exclude-author", "", "")
+	flag.StringVar(&titleRegexstr, "title-regex", "", "")
+	flag.StringVar(&typesstr, "type", "", "")
+	flag.StringVar(&sortstr, "sort", "", "")
+	flag.BoolVar(&reverse, "reverse", false, "")
+
+	positional := parseFlags()
+
+	command := FrontPage
+	var username string
+	var threadId api.ItemId
+	var itemIds []api.ItemId
+	if len(positional) > 0 {
+		switch positional[0] {
+		case "user":
+			if len(positional) != 2 {
+				return Args{}, fmt.Errorf("user command requires exactly one username\n")
+			}
+			command = User
+			username = positional[1]
+		case "thread":
+			if len(positional) != 2 {
+				return Args{}, fmt.Errorf("thread command requires exactly one item id\n")
+			}
+			id, err := api.ParseItemId(positional[1])
+			if err != nil {
+				return Args{}, err
+			}
+			command = Thread
+			threadId = id
+		case "item":
+			for _, arg := range positional[1:] {
+				id, err := api.ParseItemId(arg)
+				if err != nil {
+					return Args{}, err
+				}
+				itemIds = append(itemIds, id)
+			}
+			if len(itemIds) == 0 {
+				// Rather than waiting on the user to type ids in.
+				if formatting.IsTerminal(os.Stdin) {
+					return Args{}, fmt.Errorf("item command requires item ids as arguments or on stdin\n")
+				}
+				ids, err := readItemIds(os.Stdin)
+				if err != nil {
+					return Args{}, err
+				}
+				itemIds = ids
+			}
+			command = Item
+		case "cache":
+			if len(positional) != 2 {
+				return Args{}, fmt.Errorf("cache command requires one of clear, stats\n")
+			}
+			switch positional[1] {
+			case "clear":
+				command = CacheClear
+			case "stats":
+				command = CacheStats
+			default:
+				return Args{}, fmt.Errorf("invalid cache command: %s\n", positional[1])
+			}
+		default:
+			return Args{}, fmt.Errorf("invalid command: %s\n", positional[0])
+		}
+		if len(query) > 0 {
+			return Args{}, fmt.Errorf("query invalid with %s command\n", positional[0])
+		}
+	} else if len(query) > 0 {
+		command = Search
+	}
 
-	flag.Parse()
+	if submissions < 0 {
+		return Args{}, fmt.Errorf("invalid submissions: %d\n", submissions)
+	}
+	if depth < 0 {
+		return Args{}, fmt.Errorf("invalid depth: %d\n", depth)
+	}
+	if timeout < 0 {
+		return Args{}, fmt.Errorf("invalid timeout: %s\n", timeout)
+	}
+	if width < 0 {
+		return Args{}, fmt.Errorf("invalid width: %d\n", width)
+	}
+	if minScore < 0 {
+		return Args{}, fmt.Errorf("invalid min score: %d\n", minScore)
+	}
+	if minComments < 0 {
+		return Args{}, fmt.Errorf("invalid min comments: %d\n", minComments)
+	}
+	if maxAge < 0 {
+		return Args{}, fmt.Errorf("invalid max age: %s\n", maxAge)
+	}
 
 	var frontPageRanking *api.FrontPageItemsRanking
 	var searchResultsRanking *api.SearchItemsRanking
@@ -108,6 +513,12 @@ func ArgsFromCli() (Args, error) {
 			frontPageRanking = api.New.ToPointer()
 		case "best":
 			frontPageRanking = api.Best.ToPointer()
+		case "ask":
+			frontPageRanking = api.Ask.ToPointer()
+		case "show":
+			frontPageRanking = api.Show.ToPointer()
+		case "jobs":
+			frontPageRanking = api.Jobs.ToPointer()
 		default:
 			return Args{}, fmt.Errorf("invalid front page ranking: %s\n", ranking)
 		}
@@ -118,36 +529,204 @@ func ArgsFromCli() (Args, error) {
 		if len(query) == 0 {
 			return Args{}, fmt.Errorf("tags invalid without query\n")
 		}
+		if comments {
+			// E.g. to search the comments of a given user.
+			tags = "comment," + tags
+		}
+	} else if comments {
+		tags = "comment"
 	} else if len(query) > 0 {
 		// Default to stories.
 		tags = "story"
 	}
 
-	var style formatting.Style
-	switch stylestr {
-	case "":
-		fallthrough
-	case "plain":
-		style = formatting.Plain
-	case "md":
-		fallthrough
-	case "markdown":
-		style = formatting.Markdown
-	case "json":
-		style = formatting.Json
-	case "csv":
-		style = formatting.Csv
-	default:
-		return Args{}, fmt.Errorf("invalid style: %s\n", stylestr)
+	if len(query) == 0 {
+		// Like --tags, these only apply to search.
+		switch {
+		case len(sincestr) > 0:
+			return Args{}, fmt.Errorf("since invalid without query\n")
+		case len(untilstr) > 0:
+			return Args{}, fmt.Errorf("until invalid without query\n")
+		case minPoints != 0:
+			return Args{}, fmt.Errorf("min points invalid without query\n")
+		case refetch:
+			return Args{}, fmt.Errorf("refetch invalid without query\n")
+		case comments:
+			return Args{}, fmt.Errorf("comments invalid without query\n")
+		}
+	}
+	if minPoints < 0 {
+		return Args{}, fmt.Errorf("invalid min points: %d\n", minPoints)
+	}
+
+	if len(formatFile) > 0 {
+		if len(format) > 0 {
+			return Args{}, fmt.Errorf("format invalid with format file\n")
+		}
+		contents, err := os.ReadFile(formatFile)
+		if err != nil {
+			return Args{}, fmt.Errorf("cannot read format file: %s\n", err.Error())
+		}
+		// Items are already written one per line.
+		format = strings.TrimSuffix(string(contents), "\n")
+	}
+
+	if len(stylestr) == 0 {
+		stylestr = string(formatting.Plain)
+	}
+	style, err := formatting.ParseStyle(stylestr)
+	if err != nil {
+		return Args{}, err
+	}
+	if submissions > 0 && (style == formatting.Csv || style == formatting.Tsv) {
+		// Users and items have different columns, so they cannot share a table.
+		return Args{}, fmt.Errorf("submissions invalid with %s style\n", style)
+	}
+	if submissions > 0 && len(format) > 0 {
+		// Profiles are written in the style, which templated items would break.
+		return Args{}, fmt.Errorf("submissions invalid with format\n")
+	}
+
+	var fields []string
+	if len(fieldsstr) > 0 {
+		fields, err = formatting.ParseFields(fieldsstr)
+		if err != nil {
+			return Args{}, err
+		}
+	}
+
+	timeFormat, err := formatting.ParseTimeFormat(timestr)
+	if err != nil {
+		return Args{}, err
+	}
+
+	var location *time.Location
+	if len(tz) > 0 {
+		location, err = time.LoadLocation(tz)
+		if err != nil {
+			return Args{}, fmt.Errorf("invalid time zone: %s\n", tz)
+		}
+	}
+
+	locale, err := formatting.ParseLocale(localestr)
+	if err != nil {
+		return Args{}, err
+	}
+
+	// Dates are in the time zone that times are shown in.
+	now := time.Now()
+	dateLocation := time.Local
+	if location != nil {
+		dateLocation = location
+	}
+	var since, until time.Time
+	if len(sincestr) > 0 {
+		since, err = parseTime(sincestr, now, dateLocation)
+		if err != nil {
+			return Args{}, fmt.Errorf("invalid since: %s\n", sincestr)
+		}
+	}
+	if len(untilstr) > 0 {
+		until, err = parseTime(untilstr, now, dateLocation)
+		if err != nil {
+			return Args{}, fmt.Errorf("invalid until: %s\n", untilstr)
+		}
+	}
+
+	var titleRegex *regexp.Regexp
+	if len(titleRegexstr) > 0 {
+		titleRegex, err = regexp.Compile(titleRegexstr)
+		if err != nil {
+			return Args{}, fmt.Errorf("invalid title regex: %s\n", titleRegexstr)
+		}
+	}
+
+	var types []api.ItemType
+	if len(typesstr) > 0 {
+		types, err = filter.ParseTypes(typesstr)
+		if err != nil {
+			return Args{}, err
+		}
+	}
+
+	var sortKey *sorting.Key
+	if len(sortstr) > 0 {
+		key, err := sorting.ParseKey(sortstr)
+		if err != nil {
+			return Args{}, err
+		}
+		sortKey = &key
+	} else if reverse {
+		return Args{}, fmt.Errorf("reverse invalid without sort\n")
 	}
 
 	return Args{
 		Version:              version,
+		Command:              command,
 		RankingFrontPage:     frontPageRanking,
 		RankingSearchResults: searchResultsRanking,
 		Limit:                limit,
 		Style:                style,
 		Query:                query,
 		Tags:                 tags,
+		Since:                since,
+		Until:                until,
+		MinPoints:            minPoints,
+		Refetch:              refetch,
+		Username:             username,
+		Submissions:          submissions,
+		ThreadId:             threadId,
+		ItemIds:              itemIds,
+		Depth:                depth,
+		Timeout:              timeout,
+		AllowPartial:         allowPartial,
+		NoCache:              noCache,
+		ShowDead:             showDead,
+		Width:                width,
+		Format:               format,
+		Fields:               fields,
+		CsvHeader:            csvHeader,
+		Time:                 timeFormat,
+		Location:             location,
+		Locale:               locale,
+		MinScore:             minScore,
+		MinComments:          minComments,
+		MaxAge:               maxAge,
+		Domains:              splitList(domains),
+		ExcludeDomains:       splitList(excludeDomains),
+		Authors:              splitList(authors),
+		ExcludeAuthors:       splitList(excludeAuthors),
+		TitleRegex:           titleRegex,
+		Types:                types,
+		Sort:                 sortKey,
+		Reverse:              reverse,
 	}, nil
 }
+
+// Splits a comma-separated list, ignoring empty elements.
+func splitList(list string) []string {
+	var elements []string
+	for _, element := range strings.Split(list, ",") {
+		if element = strings.TrimSpace(element); len(element) > 0 {
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+// Parses a point in time given as a date, e.g. 2024-01-31, a date and time in
+// RFC 3339 format, or a duration before now, e.g. 48h. Dates are midnight in
+// the given location.
+func parseTime(value string, now time.Time, location *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, location); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	ago, err := time.ParseDuration(value)
+	if err != nil || ago < 0 {
+		return time.Time{}, fmt.Errorf("invalid time: %s\n", value)
+	}
+	return now.Add(-ago), nil
+}
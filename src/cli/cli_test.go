@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// Parses the given commandline arguments as if hn had been run with them.
+func argsFromCli(t *testing.T, args ...string) (Args, error) {
+	commandLine, osArgs := flag.CommandLine, os.Args
+	t.Cleanup(func() { flag.CommandLine, os.Args = commandLine, osArgs })
+	flag.CommandLine = flag.NewFlagSet("hn", flag.ContinueOnError)
+	os.Args = append([]string{"hn"}, args...)
+	return ArgsFromCli()
+}
+
+func TestSubmissionsAreInvalidWithFormat(t *testing.T) {
+	_, err := argsFromCli(t, "user", "pg", "--submissions", "5", "--format", "{{.Id}}")
+	assert.ErrorContains(t, err, "submissions invalid with format")
+
+	args, err := argsFromCli(t, "user", "pg", "--format", "{{.Id}}")
+	assert.Nil(t, err)
+	assert.Equal(t, User, args.Command)
+
+	args, err = argsFromCli(t, "user", "pg", "--submissions", "5")
+	assert.Nil(t, err)
+	assert.Equal(t, 5, args.Submissions)
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	tokyo := time.FixedZone("Asia/Tokyo", 9*60*60)
+
+	for _, test := range []struct {
+		value    string
+		location *time.Location
+		expected time.Time
+	}{
+		{"2024-01-15", time.UTC, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-15", tokyo, time.Date(2024, 1, 14, 15, 0, 0, 0, time.UTC)},
+		{"2024-01-15T09:30:00Z", tokyo, time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)},
+		{"2024-01-15T09:30:00+02:00", time.UTC, time.Date(2024, 1, 15, 7, 30, 0, 0, time.UTC)},
+		{"48h", tokyo, time.Date(2024, 1, 29, 12, 0, 0, 0, time.UTC)},
+		{"90m", time.UTC, time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"0s", time.UTC, now},
+	} {
+		parsed, err := parseTime(test.value, now, test.location)
+		assert.Nil(t, err, test.value)
+		assert.True(t, test.expected.Equal(parsed), "%s: expected %s, got %s", test.value, test.expected, parsed)
+	}
+
+	for _, value := range []string{"", "yesterday", "2024-13-01", "2024-01-15 09:30", "-48h", "48"} {
+		_, err := parseTime(value, now, time.UTC)
+		assert.NotNil(t, err, value)
+		assert.ErrorContains(t, err, "invalid time: "+value, value)
+	}
+}
+
+func TestReadItemIds(t *testing.T) {
+	for _, test := range []struct {
+		input    string
+		expected []api.ItemId
+	}{
+		{"", nil},
+		{"\n  \n", nil},
+		{"8863\n8864\n", []api.ItemId{8863, 8864}},
+		{"8863\n\n  \n8864", []api.ItemId{8863, 8864}},
+		{" 8863 \r\nhttps://news.ycombinator.com/item?id=8864\nnews.ycombinator.com/item?id=8865\n", []api.ItemId{8863, 8864, 8865}},
+	} {
+		ids, err := readItemIds(strings.NewReader(test.input))
+		assert.Nil(t, err, test.input)
+		assert.Equal(t, test.expected, ids, test.input)
+	}
+
+	for _, input := range []string{"8863\nabc\n", "0", "https://news.ycombinator.com/user?id=pg"} {
+		_, err := readItemIds(strings.NewReader(input))
+		assert.NotNil(t, err, input)
+		assert.ErrorContains(t, err, "invalid item id", input)
+	}
+}
+
+func TestReadItemIdsFailsIfReaderFails(t *testing.T) {
+	_, err := readItemIds(iotest.ErrReader(errors.New("broken pipe")))
+
+	assert.ErrorContains(t, err, "error reading item ids: broken pipe")
+}
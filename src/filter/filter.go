@@ -0,0 +1,173 @@
+// Package filter decides which fetched items are displayed, e.g. only stories
+// with enough points from the last few hours.
+package filter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+// Reports whether an item should be kept.
+type Filter func(item *api.Item) bool
+
+// Keeps the items kept by all of the filters, i.e. all items if there are none.
+func All(filters ...Filter) Filter {
+	return func(item *api.Item) bool {
+		for _, filter := range filters {
+			if !filter(item) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Returns the items kept by the filter, in order.
+func Apply(items []api.Item, filter Filter) []api.Item {
+	kept := []api.Item{}
+	for i := range items {
+		if filter(&items[i]) {
+			kept = append(kept, items[i])
+		}
+	}
+	return kept
+}
+
+// Keeps items that are neither deleted nor dead.
+func Live() Filter {
+	return func(item *api.Item) bool {
+		return !item.IsDeleted() && !item.IsDead()
+	}
+}
+
+// Keeps items with at least the given score. Items without one have none.
+func MinScore(min int) Filter {
+	return func(item *api.Item) bool {
+		n := 0
+		if item.Score != nil {
+			n = int(*item.Score)
+		}
+		return n >= min
+	}
+}
+
+// Keeps items with at least the given number of comments. Items without a
+// comment count, e.g. comments themselves, have none.
+func MinComments(min int) Filter {
+	return func(item *api.Item) bool {
+		n := 0
+		if item.Descendants != nil {
+			n = int(*item.Descendants)
+		}
+		return n >= min
+	}
+}
+
+// Keeps items posted at most the given duration before now. Items without a
+// time are never kept.
+func MaxAge(age time.Duration, now time.Time) Filter {
+	return func(item *api.Item) bool {
+		return item.Time != nil && !time.Unix(*item.Time, 0).Before(now.Add(-age))
+	}
+}
+
+// Keeps items linking to any of the given domains or their subdomains, e.g.
+// "github.com" also keeps items linking to "gist.github.com". Items without a
+// url are never kept.
+func Domain(domains ...string) Filter {
+	return func(item *api.Item) bool {
+		return matchesDomain(item, domains)
+	}
+}
+
+// Keeps items not linking to any of the given domains or their subdomains,
+// including items without a url.
+func ExcludeDomain(domains ...string) Filter {
+	return func(item *api.Item) bool {
+		return !matchesDomain(item, domains)
+	}
+}
+
+// Keeps items by any of the given users.
+func Author(authors ...string) Filter {
+	return func(item *api.Item) bool {
+		return matchesAuthor(item, authors)
+	}
+}
+
+// Keeps items not by any of the given users, including those whose author is
+// unknown.
+func ExcludeAuthor(authors ...string) Filter {
+	return func(item *api.Item) bool {
+		return !matchesAuthor(item, authors)
+	}
+}
+
+// Keeps items whose title matches the regex. Items without a title, e.g.
+// comments, are never kept.
+func TitleRegex(regex *regexp.Regexp) Filter {
+	return func(item *api.Item) bool {
+		return item.Title != nil && regex.MatchString(*item.Title)
+	}
+}
+
+// Keeps items of any of the given types.
+func Type(types ...api.ItemType) Filter {
+	return func(item *api.Item) bool {
+		for _, t := range types {
+			if item.Type == t {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Parses a comma-separated list of item types, e.g. "story,poll".
+func ParseTypes(list string) ([]api.ItemType, error) {
+	var types []api.ItemType
+	for _, name := range strings.Split(list, ",") {
+		switch t := api.ItemType(strings.TrimSpace(name)); t {
+		case api.Job, api.Story, api.Comment, api.Poll, api.PollOpt:
+			types = append(types, t)
+		default:
+			return nil, fmt.Errorf("invalid type: %s\n", name)
+		}
+	}
+	return types, nil
+}
+
+func matchesDomain(item *api.Item, domains []string) bool {
+	if item.Url == nil {
+		return false
+	}
+	parsed, err := url.Parse(*item.Url)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "www."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAuthor(item *api.Item, authors []string) bool {
+	if item.By == nil {
+		return false
+	}
+	for _, author := range authors {
+		if *item.By == author {
+			return true
+		}
+	}
+	return false
+}
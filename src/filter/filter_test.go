@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr[T any](t T) *T {
+	return &t
+}
+
+var (
+	now = time.Unix(10000000, 0)
+
+	story = api.Item{
+		Id:          1,
+		Type:        api.Story,
+		By:          ptr("storyuser"),
+		Time:        ptr(now.Add(-2 * time.Hour).Unix()),
+		Url:         ptr("https://www.github.com/fmenozzi/hn"),
+		Score:       ptr(int32(150)),
+		Title:       ptr("Show HN: A commandline HN client"),
+		Descendants: ptr(int32(40)),
+	}
+
+	ask = api.Item{
+		Id:          2,
+		Type:        api.Story,
+		By:          ptr("askuser"),
+		Time:        ptr(now.Add(-10 * time.Hour).Unix()),
+		Text:        ptr("Ask text"),
+		Score:       ptr(int32(20)),
+		Title:       ptr("Ask HN: What are you working on?"),
+		Descendants: ptr(int32(300)),
+	}
+
+	medium = api.Item{
+		Id:          3,
+		Type:        api.Story,
+		By:          ptr("mediumuser"),
+		Time:        ptr(now.Add(-30 * time.Minute).Unix()),
+		Url:         ptr("https://blog.medium.com/some-post"),
+		Score:       ptr(int32(5)),
+		Title:       ptr("Some post"),
+		Descendants: ptr(int32(0)),
+	}
+
+	comment = api.Item{
+		Id:     4,
+		Type:   api.Comment,
+		By:     ptr("commentuser"),
+		Time:   ptr(now.Add(-time.Hour).Unix()),
+		Text:   ptr("Comment text"),
+		Parent: ptr(int32(1)),
+	}
+
+	deleted = api.Item{
+		Id:      5,
+		Type:    api.Comment,
+		Deleted: ptr(true),
+	}
+
+	items = []api.Item{story, ask, medium, comment, deleted}
+)
+
+func ids(items []api.Item) []api.ItemId {
+	ids := []api.ItemId{}
+	for _, item := range items {
+		ids = append(ids, item.Id)
+	}
+	return ids
+}
+
+func TestFilters(t *testing.T) {
+	assert.Equal(t, []api.ItemId{1, 2, 3, 4}, ids(Apply(items, Live())))
+	assert.Equal(t, []api.ItemId{1, 2}, ids(Apply(items, MinScore(20))))
+	assert.Equal(t, []api.ItemId{1, 2, 3, 4, 5}, ids(Apply(items, MinScore(0))))
+	assert.Equal(t, []api.ItemId{2}, ids(Apply(items, MinComments(100))))
+	assert.Equal(t, []api.ItemId{1, 3, 4}, ids(Apply(items, MaxAge(6*time.Hour, now))))
+	assert.Equal(t, []api.ItemId{1}, ids(Apply(items, Domain("github.com"))))
+	assert.Equal(t, []api.ItemId{3}, ids(Apply(items, Domain("medium.com", "example.com"))))
+	assert.Equal(t, []api.ItemId{1, 2, 4, 5}, ids(Apply(items, ExcludeDomain("medium.com"))))
+	assert.Equal(t, []api.ItemId{2, 4}, ids(Apply(items, Author("askuser", "commentuser"))))
+	assert.Equal(t, []api.ItemId{1, 3, 5}, ids(Apply(items, ExcludeAuthor("askuser", "commentuser"))))
+	assert.Equal(t, []api.ItemId{1, 2}, ids(Apply(items, TitleRegex(regexp.MustCompile(`^(Show|Ask) HN`)))))
+	assert.Equal(t, []api.ItemId{4, 5}, ids(Apply(items, Type(api.Comment))))
+}
+
+func TestDomainMatchesWholeLabelsOnly(t *testing.T) {
+	notGithub := api.Item{Id: 6, Type: api.Story, Url: ptr("https://notgithub.com")}
+
+	assert.Equal(t, []api.ItemId{1}, ids(Apply([]api.Item{story, notGithub}, Domain("github.com"))))
+	assert.Equal(t, []api.ItemId{1}, ids(Apply([]api.Item{story, notGithub}, Domain("www.GitHub.com"))))
+}
+
+func TestAllKeepsItemsKeptByEveryFilter(t *testing.T) {
+	filter := All(Live(), MinScore(10), MaxAge(6*time.Hour, now), ExcludeDomain("medium.com"))
+
+	assert.Equal(t, []api.ItemId{1}, ids(Apply(items, filter)))
+	assert.Equal(t, []api.ItemId{1, 2, 3, 4, 5}, ids(Apply(items, All())))
+}
+
+func TestParseTypes(t *testing.T) {
+	types, err := ParseTypes("story, poll,job")
+	assert.Nil(t, err)
+	assert.Equal(t, []api.ItemType{api.Story, api.Poll, api.Job}, types)
+
+	_, err = ParseTypes("story,article")
+	assert.Equal(t, "invalid type: article\n", err.Error())
+}
@@ -0,0 +1,106 @@
+package formatting
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+func init() {
+	RegisterStyle(Csv, NewCsvFormatter)
+	RegisterStyle(Tsv, NewTsvFormatter)
+}
+
+// Escapes the characters that cannot appear as is in tsv fields.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// Formats items as csv or tsv records, one per item.
+//
+// Similar to json, this is a "raw" dump of the data as fetched without any
+// additional post-processing, unless derived fields are asked for.
+type csvFormatter struct {
+	fields []itemField
+	header bool
+
+	// Writes a single record, as csv or tsv.
+	writeRecord func(record []string, w io.Writer)
+}
+
+func NewCsvFormatter(opts Options) Formatter {
+	return &csvFormatter{fields: opts.itemFields(), header: opts.Header, writeRecord: writeCsvRecord}
+}
+
+// Like `NewCsvFormatter`, but fields are separated by tabs instead. Rather than
+// being quoted, backslashes, tabs and newlines in fields are escaped as `\\`,
+// `\t` and `\n`.
+func NewTsvFormatter(opts Options) Formatter {
+	return &csvFormatter{fields: opts.itemFields(), header: opts.Header, writeRecord: writeTsvRecord}
+}
+
+func (f *csvFormatter) Begin(w io.Writer) {
+	if f.header {
+		names := make([]string, len(f.fields))
+		for i, field := range f.fields {
+			names[i] = field.name
+		}
+		f.writeRecord(names, w)
+	}
+}
+
+func (f *csvFormatter) End(w io.Writer) {}
+
+func (f *csvFormatter) WriteItem(item *api.Item, w io.Writer) {
+	f.writeItem(item, nil, w)
+}
+
+// Search results are written as items, with the story fields of comments
+// filled in.
+func (f *csvFormatter) WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer) {
+	f.writeItem(item, searchStory(item, result), w)
+}
+
+func (f *csvFormatter) writeItem(item *api.Item, story *api.Item, w io.Writer) {
+	record := make([]string, len(f.fields))
+	for i, field := range f.fields {
+		record[i] = field.csv(fieldItem(field, item, story))
+	}
+	f.writeRecord(record, w)
+}
+
+func (f *csvFormatter) WriteUser(user *api.User, w io.Writer) {
+	// As with items, this is the user data exactly as fetched.
+	if f.header {
+		f.writeRecord(userFieldNames, w)
+	}
+	f.writeRecord([]string{
+		user.Id,
+		intToStr(user.Created),
+		intToStr(user.Karma),
+		derefStrOr(user.About, ""),
+		idsToStr(user.Submitted),
+	}, w)
+}
+
+func (f *csvFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	// Csv has no notion of nesting, so the thread is flattened in display
+	// order. The tree can still be recovered from the parent column.
+	WriteItems(f, flattenThread(thread, nil), w)
+}
+
+func writeCsvRecord(record []string, w io.Writer) {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.WriteAll([][]string{record}); err != nil {
+		panic(fmt.Sprintf("error formatting as csv: %s", err.Error()))
+	}
+}
+
+func writeTsvRecord(record []string, w io.Writer) {
+	escaped := make([]string, len(record))
+	for i, field := range record {
+		escaped[i] = tsvEscaper.Replace(field)
+	}
+	fmt.Fprintln(w, strings.Join(escaped, "\t"))
+}
@@ -0,0 +1,250 @@
+package formatting
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+const (
+	feedTitle       = "Hacker News"
+	feedLink        = "https://news.ycombinator.com/"
+	feedDescription = "Links for the intellectually curious, ranked by readers."
+
+	dublinCoreNamespace = "http://purl.org/dc/elements/1.1/"
+	atomNamespace       = "http://www.w3.org/2005/Atom"
+)
+
+func init() {
+	RegisterStyle(Rss, NewRssFormatter)
+	RegisterStyle(Atom, NewAtomFormatter)
+}
+
+type rssItem struct {
+	XMLName     xml.Name `xml:"item"`
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        rssGuid  `xml:"guid"`
+	Creator     string   `xml:"dc:creator,omitempty"`
+	PubDate     string   `xml:"pubDate,omitempty"`
+	Comments    string   `xml:"comments"`
+	Description string   `xml:"description,omitempty"`
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	XMLName   xml.Name     `xml:"entry"`
+	Title     string       `xml:"title"`
+	Links     []atomLink   `xml:"link"`
+	Id        string       `xml:"id"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published,omitempty"`
+	Author    *atomAuthor  `xml:"author"`
+	Content   *atomContent `xml:"content"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+	Uri  string `xml:"uri"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Formats items as an RSS 2.0 feed, with an `<item>` per item.
+//
+// Feeds have no place for user profiles, so only a user's submissions are
+// written, as a feed that is empty if there are none. Threads are written as a
+// feed of the item and its comments.
+type rssFormatter struct {
+	opts Options
+}
+
+func NewRssFormatter(opts Options) Formatter {
+	return &rssFormatter{opts: opts}
+}
+
+func (f *rssFormatter) Begin(w io.Writer) {
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintf(w, "<rss version=\"2.0\" xmlns:dc=\"%s\">\n", dublinCoreNamespace)
+	fmt.Fprint(w, "  <channel>\n")
+	fmt.Fprintf(w, "    <title>%s</title>\n", feedTitle)
+	fmt.Fprintf(w, "    <link>%s</link>\n", feedLink)
+	fmt.Fprintf(w, "    <description>%s</description>\n", feedDescription)
+	fmt.Fprintf(w, "    <lastBuildDate>%s</lastBuildDate>\n", f.opts.Clock.Now().UTC().Format(time.RFC1123Z))
+}
+
+func (f *rssFormatter) WriteItem(item *api.Item, w io.Writer) {
+	f.writeItem(item, nil, w)
+}
+
+// Comments found by search are titled after the story they are on, which
+// their description links to as rss has no place for related links.
+func (f *rssFormatter) WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer) {
+	f.writeItem(item, searchStory(item, result), w)
+}
+
+func (f *rssFormatter) writeItem(item *api.Item, story *api.Item, w io.Writer) {
+	postUrl := itemPostUrl(item)
+	rss := rssItem{
+		Title:       feedItemTitle(item, story),
+		Link:        itemUrl(item),
+		Guid:        rssGuid{IsPermaLink: true, Value: postUrl},
+		Creator:     derefStrOr(item.By, ""),
+		Comments:    postUrl,
+		Description: feedItemContent(item),
+	}
+	if story != nil {
+		rss.Description += fmt.Sprintf("<p>on: <a href=\"%s\">%s</a></p>", itemPostUrl(story), html.EscapeString(storyTitle(story)))
+	}
+	if item.Time != nil {
+		rss.PubDate = time.Unix(*item.Time, 0).UTC().Format(time.RFC1123Z)
+	}
+	writeFeedElement(rss, "    ", w)
+}
+
+func (f *rssFormatter) End(w io.Writer) {
+	fmt.Fprint(w, "  </channel>\n</rss>\n")
+}
+
+func (f *rssFormatter) WriteUser(user *api.User, w io.Writer) {}
+
+// Writes a feed of the user's submissions, which is empty if there are none, so
+// that there is always a feed.
+func (f *rssFormatter) WriteUserSubmissions(user *api.User, submissions []api.Item, w io.Writer) {
+	WriteItems(f, submissions, w)
+}
+
+func (f *rssFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	WriteItems(f, flattenThread(thread, nil), w)
+}
+
+// Formats items as an Atom feed, with an `<entry>` per item.
+//
+// As with rss, user profiles are not written and threads are written as a
+// feed of the item and its comments.
+type atomFormatter struct {
+	opts Options
+
+	// When the current feed was generated, which entries without a time of
+	// their own fall back to.
+	updated string
+}
+
+func NewAtomFormatter(opts Options) Formatter {
+	return &atomFormatter{opts: opts}
+}
+
+func (f *atomFormatter) Begin(w io.Writer) {
+	f.updated = f.opts.Clock.Now().UTC().Format(time.RFC3339)
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintf(w, "<feed xmlns=\"%s\">\n", atomNamespace)
+	fmt.Fprintf(w, "  <title>%s</title>\n", feedTitle)
+	fmt.Fprintf(w, "  <subtitle>%s</subtitle>\n", feedDescription)
+	fmt.Fprintf(w, "  <link rel=\"alternate\" href=\"%s\"></link>\n", feedLink)
+	fmt.Fprintf(w, "  <id>%s</id>\n", feedLink)
+	fmt.Fprintf(w, "  <updated>%s</updated>\n", f.updated)
+	// Entries without an author of their own, e.g. deleted ones, fall back to
+	// the feed's author.
+	fmt.Fprintf(w, "  <author>\n    <name>%s</name>\n  </author>\n", feedTitle)
+}
+
+func (f *atomFormatter) WriteItem(item *api.Item, w io.Writer) {
+	f.writeEntry(item, nil, w)
+}
+
+// Comments found by search are titled after the story they are on, which they
+// also link to as a related link.
+func (f *atomFormatter) WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer) {
+	f.writeEntry(item, searchStory(item, result), w)
+}
+
+func (f *atomFormatter) writeEntry(item *api.Item, story *api.Item, w io.Writer) {
+	postUrl := itemPostUrl(item)
+	entry := atomEntry{
+		Title: feedItemTitle(item, story),
+		Links: []atomLink{
+			{Rel: "alternate", Href: itemUrl(item)},
+			{Rel: "replies", Type: "text/html", Href: postUrl},
+		},
+		Id:      postUrl,
+		Updated: f.updated,
+	}
+	if story != nil {
+		entry.Links = append(entry.Links, atomLink{Rel: "related", Type: "text/html", Href: itemPostUrl(story)})
+	}
+	if item.Time != nil {
+		entry.Updated = time.Unix(*item.Time, 0).UTC().Format(time.RFC3339)
+		entry.Published = entry.Updated
+	}
+	if item.By != nil {
+		entry.Author = &atomAuthor{Name: *item.By, Uri: userBaseUrl + *item.By}
+	}
+	if content := feedItemContent(item); len(content) > 0 {
+		entry.Content = &atomContent{Type: "html", Value: content}
+	}
+	writeFeedElement(entry, "  ", w)
+}
+
+func (f *atomFormatter) End(w io.Writer) {
+	fmt.Fprint(w, "</feed>\n")
+}
+
+func (f *atomFormatter) WriteUser(user *api.User, w io.Writer) {}
+
+// As with rss, writes a feed of the user's submissions, even if there are none.
+func (f *atomFormatter) WriteUserSubmissions(user *api.User, submissions []api.Item, w io.Writer) {
+	WriteItems(f, submissions, w)
+}
+
+func (f *atomFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	WriteItems(f, flattenThread(thread, nil), w)
+}
+
+// Returns the title of the item's feed entry. Items without titles of their
+// own, i.e. comments and poll options, are titled with a preview of their text,
+// unless the story a comment is on is given.
+func feedItemTitle(item *api.Item, story *api.Item) string {
+	if story != nil {
+		return fmt.Sprintf("Comment by %s on %s", itemBy(item), storyTitle(story))
+	}
+	switch item.Type {
+	case api.Comment, api.PollOpt:
+		return Truncate(itemContent(item, item.Text, htmlToLine), defaultPreviewWidth)
+	default:
+		return itemContent(item, item.Title, htmlToLine)
+	}
+}
+
+// Returns the HTML content of the item's feed entry, if any. Feeds carry HTML
+// as escaped text, so HN's HTML is passed through as is.
+func feedItemContent(item *api.Item) string {
+	if item.IsDeleted() || item.Text == nil {
+		return ""
+	}
+	return *item.Text
+}
+
+func writeFeedElement(element any, indent string, w io.Writer) {
+	encoded, err := xml.MarshalIndent(element, indent, "  ")
+	if err != nil {
+		panic(fmt.Sprintf("error formatting items as feed: %s", err.Error()))
+	}
+	fmt.Fprintf(w, "%s\n", encoded)
+}
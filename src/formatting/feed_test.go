@@ -0,0 +1,192 @@
+package formatting
+
+import (
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// Items covering each type, HTML text, a missing url and a deleted item.
+func feedItems() []api.Item {
+	story, comment := story, comment
+	comment.Text = ptr(`I&#x27;d say <i>vim</i>.<p>See <a href="https:&#x2F;&#x2F;vim.org">vim.org</a>`)
+	ask := api.Item{
+		Id:          6,
+		Type:        api.Story,
+		Score:       intptr(5),
+		By:          ptr("askuser"),
+		Time:        ptr(now.Add(-2 * time.Hour).Unix()), // 2 hours ago
+		Descendants: intptr(1),
+		Title:       ptr("Ask HN: Tabs &amp; spaces?"),
+		Text:        ptr("Which do you <i>prefer</i>?"),
+	}
+	deleted := api.Item{Id: 7, Type: api.Story, Deleted: ptr(true)}
+	return []api.Item{job, story, poll, pollopt, comment, ask, deleted}
+}
+
+// Compares the output to the golden file, rewriting the file instead if
+// -update is passed.
+func assertGolden(t *testing.T, name string, output []byte) {
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, output, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(golden), string(output))
+}
+
+// What a feed reader sees of an rss feed.
+type decodedRss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+		Items []struct {
+			Title       string `xml:"title"`
+			Link        string `xml:"link"`
+			Guid        string `xml:"guid"`
+			Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			PubDate     string `xml:"pubDate"`
+			Comments    string `xml:"comments"`
+			Description string `xml:"description"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+// What a feed reader sees of an atom feed.
+type decodedAtom struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string   `xml:"title"`
+	Id      string   `xml:"id"`
+	Updated string   `xml:"updated"`
+	Entries []struct {
+		Title string `xml:"title"`
+		Links []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Id        string `xml:"id"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+		Author    struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Content struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"content"`
+	} `xml:"entry"`
+}
+
+func TestRssOutput(t *testing.T) {
+	items := feedItems()
+
+	var output bytes.Buffer
+	WriteItems(NewRssFormatter(fakeOptions), items, &output)
+
+	assertGolden(t, "items.rss", output.Bytes())
+
+	var feed decodedRss
+	assert.Nil(t, xml.Unmarshal(output.Bytes(), &feed))
+	assert.Equal(t, "2.0", feed.Version)
+	assert.Equal(t, "Hacker News", feed.Channel.Title)
+	assert.Len(t, feed.Channel.Items, len(items))
+	for i, decoded := range feed.Channel.Items {
+		item := items[i]
+		assert.Equal(t, itemPostUrl(&item), decoded.Guid)
+		assert.Equal(t, itemPostUrl(&item), decoded.Comments)
+		assert.Equal(t, derefStrOr(item.By, ""), decoded.Creator)
+		if item.Time != nil {
+			pubDate, err := time.Parse(time.RFC1123Z, decoded.PubDate)
+			assert.Nil(t, err)
+			assert.Equal(t, *item.Time, pubDate.Unix())
+		}
+	}
+
+	story, ask, comment := feed.Channel.Items[1], feed.Channel.Items[5], feed.Channel.Items[4]
+	assert.Equal(t, "Story title", story.Title)
+	assert.Equal(t, "www.story.url", story.Link)
+	assert.Equal(t, "Ask HN: Tabs & spaces?", ask.Title)
+	assert.Equal(t, "https://news.ycombinator.com/item?id=6", ask.Link)
+	assert.Equal(t, "Which do you <i>prefer</i>?", ask.Description)
+	assert.Equal(t, "I'd say vim. See vim.org", comment.Title)
+	assert.Equal(t, *items[4].Text, comment.Description)
+	assert.Equal(t, "[deleted]", feed.Channel.Items[6].Title)
+}
+
+func TestAtomOutput(t *testing.T) {
+	items := feedItems()
+
+	var output bytes.Buffer
+	WriteItems(NewAtomFormatter(fakeOptions), items, &output)
+
+	assertGolden(t, "items.atom", output.Bytes())
+
+	var feed decodedAtom
+	assert.Nil(t, xml.Unmarshal(output.Bytes(), &feed))
+	assert.Equal(t, "Hacker News", feed.Title)
+	assert.Equal(t, now.UTC().Format(time.RFC3339), feed.Updated)
+	assert.Len(t, feed.Entries, len(items))
+	for i, decoded := range feed.Entries {
+		item := items[i]
+		assert.Equal(t, itemPostUrl(&item), decoded.Id)
+		assert.Equal(t, derefStrOr(item.By, ""), decoded.Author.Name)
+		assert.Equal(t, "alternate", decoded.Links[0].Rel)
+		assert.Equal(t, itemUrl(&item), decoded.Links[0].Href)
+		assert.Equal(t, "replies", decoded.Links[1].Rel)
+		assert.Equal(t, itemPostUrl(&item), decoded.Links[1].Href)
+		if item.Time != nil {
+			published, err := time.Parse(time.RFC3339, decoded.Published)
+			assert.Nil(t, err)
+			assert.Equal(t, *item.Time, published.Unix())
+		}
+	}
+
+	ask, deleted := feed.Entries[5], feed.Entries[6]
+	assert.Equal(t, "Ask HN: Tabs & spaces?", ask.Title)
+	assert.Equal(t, "html", ask.Content.Type)
+	assert.Equal(t, "Which do you <i>prefer</i>?", ask.Content.Value)
+	assert.Equal(t, "[deleted]", deleted.Title)
+	assert.Equal(t, feed.Updated, deleted.Updated)
+}
+
+func TestFeedThreadOutput(t *testing.T) {
+	var output bytes.Buffer
+	NewRssFormatter(fakeOptions).WriteThread(&thread, &output)
+
+	var feed decodedRss
+	assert.Nil(t, xml.Unmarshal(output.Bytes(), &feed))
+	assert.Len(t, feed.Channel.Items, 4)
+	assert.Equal(t, "Story title", feed.Channel.Items[0].Title)
+	assert.Equal(t, "First reply", feed.Channel.Items[2].Title)
+}
+
+func TestFeedUserOutputIsAFeedOfSubmissions(t *testing.T) {
+	for _, submissions := range [][]api.Item{nil, {}, {story}} {
+		var rssOutput, atomOutput bytes.Buffer
+		WriteUser(NewRssFormatter(fakeOptions), &user, submissions, &rssOutput)
+		WriteUser(NewAtomFormatter(fakeOptions), &user, submissions, &atomOutput)
+
+		var rss decodedRss
+		assert.Nil(t, xml.Unmarshal(rssOutput.Bytes(), &rss))
+		assert.Len(t, rss.Channel.Items, len(submissions))
+		var atom decodedAtom
+		assert.Nil(t, xml.Unmarshal(atomOutput.Bytes(), &atom))
+		assert.Len(t, atom.Entries, len(submissions))
+	}
+}
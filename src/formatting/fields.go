@@ -0,0 +1,214 @@
+package formatting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+// A column of csv and tsv output, or a field of json output, for items.
+type itemField struct {
+	name string
+
+	// The field's value in json output, with nil written as null.
+	json func(item *api.Item) any
+
+	// The field's value in csv and tsv output.
+	csv func(item *api.Item) string
+}
+
+// The fields of an item as fetched, in the order they are output by default.
+var rawItemFields = []itemField{
+	{"id", func(item *api.Item) any { return item.Id }, func(item *api.Item) string { return intToStr(item.Id) }},
+	{"deleted", func(item *api.Item) any { return item.Deleted }, func(item *api.Item) string { return derefBoolOrEmptyStr(item.Deleted) }},
+	{"type", func(item *api.Item) any { return item.Type }, func(item *api.Item) string { return string(item.Type) }},
+	{"by", func(item *api.Item) any { return item.By }, func(item *api.Item) string { return derefStrOr(item.By, "") }},
+	{"time", func(item *api.Item) any { return item.Time }, func(item *api.Item) string { return derefIntOr(item.Time, 0) }},
+	{"text", func(item *api.Item) any { return item.Text }, func(item *api.Item) string { return derefStrOr(item.Text, "") }},
+	{"dead", func(item *api.Item) any { return item.Dead }, func(item *api.Item) string { return derefBoolOrEmptyStr(item.Dead) }},
+	{"parent", func(item *api.Item) any { return item.Parent }, func(item *api.Item) string { return derefIntOr(item.Parent, 0) }},
+	{"poll", func(item *api.Item) any { return item.Poll }, func(item *api.Item) string { return derefIntOr(item.Poll, 0) }},
+	{"kids", func(item *api.Item) any { return item.Kids }, func(item *api.Item) string { return idsToStr(item.Kids) }},
+	{"url", func(item *api.Item) any { return item.Url }, func(item *api.Item) string { return derefStrOr(item.Url, "") }},
+	{"score", func(item *api.Item) any { return item.Score }, func(item *api.Item) string { return derefIntOr(item.Score, 0) }},
+	{"title", func(item *api.Item) any { return item.Title }, func(item *api.Item) string { return derefStrOr(item.Title, "") }},
+	{"parts", func(item *api.Item) any { return item.Parts }, func(item *api.Item) string { return idsToStr(item.Parts) }},
+	{"descendants", func(item *api.Item) any { return item.Descendants }, func(item *api.Item) string { return derefIntOr(item.Descendants, 0) }},
+}
+
+// Fields computed from those of an item as fetched, which are only output if
+// asked for.
+var derivedItemFields = []itemField{
+	{"post_url", func(item *api.Item) any { return itemPostUrl(item) }, itemPostUrl},
+	{"domain", func(item *api.Item) any { return nilIfEmpty(itemDomain(item)) }, itemDomain},
+	{"iso_time", func(item *api.Item) any { return nilIfEmpty(itemIsoTime(item)) }, itemIsoTime},
+}
+
+// Fields of the story that a comment is on, e.g. "story_title", which are only
+// known for comments found by search and only output if asked for.
+var storyItemFields = storyItemFieldsOf("id", "title", "url")
+
+var allItemFields = append(append(append([]itemField{}, rawItemFields...), derivedItemFields...), storyItemFields...)
+
+// The names of the columns of user csv and tsv output.
+var userFieldNames = []string{"id", "created", "karma", "about", "submitted"}
+
+// Returns the names of all the item fields that can be output, in order.
+func FieldNames() []string {
+	var names []string
+	for _, field := range allItemFields {
+		names = append(names, field.name)
+	}
+	return names
+}
+
+// Parses a comma-separated list of item field names, e.g. "id,title,url".
+func ParseFields(list string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := lookupItemField(name); !ok {
+			return nil, fmt.Errorf("invalid field: %s\n", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func lookupItemField(name string) (itemField, bool) {
+	for _, field := range allItemFields {
+		if field.name == name {
+			return field, true
+		}
+	}
+	return itemField{}, false
+}
+
+// Returns the item fields to output, i.e. those in `opts.Fields` or all the
+// fields as fetched by default.
+func (opts *Options) itemFields() []itemField {
+	if len(opts.Fields) == 0 {
+		return rawItemFields
+	}
+	fields := make([]itemField, len(opts.Fields))
+	for i, name := range opts.Fields {
+		field, ok := lookupItemField(name)
+		if !ok {
+			panic(fmt.Sprintf("invalid field: %s", name))
+		}
+		fields[i] = field
+	}
+	return fields
+}
+
+// Returns the story fields for the given fields of an item as fetched, which
+// are null or empty if the story is not known.
+func storyItemFieldsOf(names ...string) []itemField {
+	var fields []itemField
+	for _, field := range rawItemFields {
+		if slices.Contains(names, field.name) {
+			field := field
+			fields = append(fields, itemField{
+				"story_" + field.name,
+				func(story *api.Item) any {
+					if story == nil {
+						return nil
+					}
+					return field.json(story)
+				},
+				func(story *api.Item) string {
+					if story == nil {
+						return ""
+					}
+					return field.csv(story)
+				},
+			})
+		}
+	}
+	return fields
+}
+
+// Returns the item that the field is of, i.e. the story that the item is on,
+// which is nil if not known, for story fields, or the item itself otherwise.
+func fieldItem(field itemField, item *api.Item, story *api.Item) *api.Item {
+	for _, storyField := range storyItemFields {
+		if field.name == storyField.name {
+			return story
+		}
+	}
+	return item
+}
+
+// Returns the item's fields as a compact json object, in order, given the
+// story the item is on, if known.
+func itemFieldsJson(item *api.Item, story *api.Item, fields []itemField) []byte {
+	var buf bytes.Buffer
+	writeItemFieldsJson(item, story, fields, &buf)
+	buf.WriteString("}")
+	return buf.Bytes()
+}
+
+// Returns the thread as a compact json object of each item's fields, in order,
+// with its replies nested under it as a "replies" array.
+func threadFieldsJson(thread *api.Thread, fields []itemField) []byte {
+	var buf bytes.Buffer
+	writeItemFieldsJson(&thread.Item, nil, fields, &buf)
+	buf.WriteString(`,"replies":[`)
+	for i := range thread.Replies {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.Write(threadFieldsJson(&thread.Replies[i], fields))
+	}
+	buf.WriteString("]}")
+	return buf.Bytes()
+}
+
+// Writes the item's fields as an unterminated json object.
+func writeItemFieldsJson(item *api.Item, story *api.Item, fields []itemField, buf *bytes.Buffer) {
+	buf.WriteString("{")
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		writeJsonValue(field.name, buf)
+		buf.WriteString(":")
+		writeJsonValue(field.json(fieldItem(field, item, story)), buf)
+	}
+}
+
+func writeJsonValue(v any, buf *bytes.Buffer) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("error formatting as json: %s", err.Error()))
+	}
+	buf.Write(encoded)
+}
+
+// Returns the domain of the item's url, if it has one, e.g. "example.com".
+func itemDomain(item *api.Item) string {
+	if item.Url == nil {
+		return ""
+	}
+	return urlDomain(*item.Url)
+}
+
+// Returns the item's time in ISO 8601 format, in UTC, if it has one.
+func itemIsoTime(item *api.Item) string {
+	if item.Time == nil {
+		return ""
+	}
+	return time.Unix(*item.Time, 0).UTC().Format(time.RFC3339)
+}
+
+func nilIfEmpty(s string) *string {
+	if len(s) == 0 {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,142 @@
+package formatting
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := ParseFields("id, title,score,url,post_url,domain,iso_time")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id", "title", "score", "url", "post_url", "domain", "iso_time"}, fields)
+
+	_, err = ParseFields("id,points")
+	assert.EqualError(t, err, "invalid field: points\n")
+
+	_, err = ParseFields("id,")
+	assert.EqualError(t, err, "invalid field: \n")
+}
+
+func TestCsvOutputWithHeader(t *testing.T) {
+	var output bytes.Buffer
+	WriteItems(NewCsvFormatter(Options{Header: true}), []api.Item{job}, &output)
+
+	expectedOutput := "id,deleted,type,by,time,text,dead,parent,poll,kids,url,score,title,parts,descendants\n" +
+		"1,,job,jobuser,9978400,,,0,0,,,1,Job title,,0\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestCsvOutputWithFields(t *testing.T) {
+	story := story
+	story.Url = ptr("https://www.example.com/post")
+	opts := Options{Fields: []string{"id", "title", "score", "url", "post_url", "domain", "iso_time"}, Header: true}
+
+	var output bytes.Buffer
+	WriteItems(NewCsvFormatter(opts), []api.Item{story, comment}, &output)
+
+	expectedOutput := "id,title,score,url,post_url,domain,iso_time\n" +
+		"2,Story title,10,https://www.example.com/post,https://news.ycombinator.com/item?id=2,example.com,1970-04-14T17:46:40Z\n" +
+		"5,,0,,https://news.ycombinator.com/item?id=5,,1970-04-25T17:46:40Z\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestTsvOutput(t *testing.T) {
+	comment := comment
+	comment.Text = ptr("Tabs\tand\nnewlines, \"quotes\" and a \\ backslash")
+	opts := Options{Fields: []string{"id", "by", "text"}, Header: true}
+
+	var output bytes.Buffer
+	WriteItems(NewTsvFormatter(opts), []api.Item{comment}, &output)
+
+	expectedOutput := "id\tby\ttext\n" +
+		"5\tcommentuser\tTabs\\tand\\nnewlines, \"quotes\" and a \\\\ backslash\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestUserCsvOutputWithHeader(t *testing.T) {
+	var output bytes.Buffer
+	NewCsvFormatter(Options{Header: true}).WriteUser(&user, &output)
+
+	expectedOutput := "id,created,karma,about,submitted\n" +
+		"username,-41840000,1234,\"About text\nSecond line\",\"1,2,3\"\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestJsonOutputWithFields(t *testing.T) {
+	opts := Options{Fields: []string{"id", "title", "score", "url", "domain", "iso_time"}}
+
+	var output bytes.Buffer
+	WriteItems(NewJsonFormatter(opts), []api.Item{story, {Id: 3, Type: api.Story}}, &output)
+
+	expectedOutput := `[
+	{
+		"id": 2,
+		"title": "Story title",
+		"score": 10,
+		"url": "www.story.url",
+		"domain": null,
+		"iso_time": "1970-04-14T17:46:40Z"
+	},
+	{
+		"id": 3,
+		"title": null,
+		"score": null,
+		"url": null,
+		"domain": null,
+		"iso_time": null
+	}
+]
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestJsonlOutputWithFields(t *testing.T) {
+	opts := Options{Fields: []string{"id", "by", "post_url"}}
+
+	var output bytes.Buffer
+	WriteItems(NewJsonlFormatter(opts), []api.Item{job, comment}, &output)
+
+	expectedOutput := `{"id":1,"by":"jobuser","post_url":"https://news.ycombinator.com/item?id=1"}
+{"id":5,"by":"commentuser","post_url":"https://news.ycombinator.com/item?id=5"}
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestThreadJsonOutputWithFields(t *testing.T) {
+	opts := Options{Fields: []string{"id", "by"}}
+
+	var output bytes.Buffer
+	NewJsonFormatter(opts).WriteThread(&thread, &output)
+
+	expectedOutput := `{
+	"id": 10,
+	"by": "storyuser",
+	"replies": [
+		{
+			"id": 11,
+			"by": "firstuser",
+			"replies": [
+				{
+					"id": 12,
+					"by": "replyuser",
+					"replies": []
+				}
+			]
+		},
+		{
+			"id": 13,
+			"by": null,
+			"replies": []
+		}
+	]
+}
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
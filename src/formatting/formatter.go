@@ -0,0 +1,152 @@
+package formatting
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+// Writes items, users and threads in a particular output style.
+//
+// A collection of items is written as a call to `Begin`, then a call to
+// `WriteItem` for each item in order, then a call to `End`, so that styles
+// which wrap their items (e.g. in a json array) can do so while items are
+// written one at a time. Formatters may keep state between these calls, so
+// collections written with the same formatter must not be interleaved.
+type Formatter interface {
+	// Writes anything that comes before the items of a collection.
+	Begin(w io.Writer)
+
+	// Writes a single item of a collection.
+	WriteItem(item *api.Item, w io.Writer)
+
+	// Writes anything that comes after the items of a collection.
+	End(w io.Writer)
+
+	// Writes a user's profile.
+	WriteUser(user *api.User, w io.Writer)
+
+	// Writes an item along with its comment tree.
+	WriteThread(thread *api.Thread, w io.Writer)
+}
+
+// Implemented by formatters whose output for each item of a collection stands
+// on its own, e.g. one line per item, so that items are worth writing as soon as
+// they arrive rather than once all of them have been fetched.
+type StreamingFormatter interface {
+	Formatter
+
+	// Reports whether items are worth writing as soon as they arrive.
+	Streaming() bool
+}
+
+// Returns whether the formatter is a `StreamingFormatter` that streams.
+func IsStreaming(f Formatter) bool {
+	streaming, ok := f.(StreamingFormatter)
+	return ok && streaming.Streaming()
+}
+
+// Implemented by formatters that can fail to write an item, e.g. one executing
+// a user-supplied template. Items that fail are left out of the output.
+type FallibleFormatter interface {
+	Formatter
+
+	// Returns the first error writing an item, if any.
+	Err() error
+}
+
+// Returns the first error the formatter ran into writing items, if it is a
+// `FallibleFormatter` that ran into any.
+func FormatterErr(f Formatter) error {
+	if fallible, ok := f.(FallibleFormatter); ok {
+		return fallible.Err()
+	}
+	return nil
+}
+
+// Implemented by formatters that write a user's profile and their submissions
+// as a whole, e.g. as a single json document, rather than as a profile followed
+// by a separate collection of items.
+type UserSubmissionsFormatter interface {
+	Formatter
+
+	// Writes a user's profile along with their submissions, which are nil if
+	// none were asked for.
+	WriteUserSubmissions(user *api.User, submissions []api.Item, w io.Writer)
+}
+
+// Writes a user's profile, followed by their submissions if any were asked for,
+// i.e. if submissions is not nil.
+func WriteUser(f Formatter, user *api.User, submissions []api.Item, w io.Writer) {
+	if submissionsFormatter, ok := f.(UserSubmissionsFormatter); ok {
+		submissionsFormatter.WriteUserSubmissions(user, submissions, w)
+		return
+	}
+	f.WriteUser(user, w)
+	if len(submissions) > 0 {
+		WriteItems(f, submissions, w)
+	}
+}
+
+// Makes a formatter with the given options.
+type NewFormatterFunc func(opts Options) Formatter
+
+var (
+	// Registered styles and how to make their formatters.
+	formatters = map[Style]NewFormatterFunc{}
+
+	// Names by which registered styles can be referred to, including aliases.
+	styleNames = map[string]Style{}
+)
+
+// Registers a style, along with any aliases for it, so that it can be looked up
+// with `ParseStyle` and formatted with `NewFormatter`. Panics if the style or
+// any of its aliases is already registered.
+func RegisterStyle(style Style, newFormatter NewFormatterFunc, aliases ...string) {
+	for _, name := range append([]string{string(style)}, aliases...) {
+		if _, ok := styleNames[name]; ok {
+			panic(fmt.Sprintf("style already registered: %s", name))
+		}
+		styleNames[name] = style
+	}
+	formatters[style] = newFormatter
+}
+
+// Returns the registered style with the given name or alias.
+func ParseStyle(name string) (Style, error) {
+	style, ok := styleNames[name]
+	if !ok {
+		return "", fmt.Errorf("invalid style: %s\n", name)
+	}
+	return style, nil
+}
+
+// Returns the names and aliases of all registered styles, sorted.
+func StyleNames() []string {
+	names := make([]string, 0, len(styleNames))
+	for name := range styleNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Makes a formatter for the given registered style.
+func NewFormatter(style Style, opts Options) (Formatter, error) {
+	newFormatter, ok := formatters[style]
+	if !ok {
+		return nil, fmt.Errorf("invalid style: %s\n", style)
+	}
+	return newFormatter(opts), nil
+}
+
+// Writes the items as a collection with the given formatter.
+func WriteItems(f Formatter, items []api.Item, w io.Writer) {
+	f.Begin(w)
+	for i := range items {
+		f.WriteItem(&items[i], w)
+	}
+	f.End(w)
+}
@@ -0,0 +1,84 @@
+package formatting
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+// Writes the ids of items, users and threads, one per line.
+type idFormatter struct{}
+
+func (f *idFormatter) Begin(w io.Writer) { io.WriteString(w, "begin\n") }
+func (f *idFormatter) WriteItem(item *api.Item, w io.Writer) {
+	io.WriteString(w, intToStr(item.Id)+"\n")
+}
+func (f *idFormatter) End(w io.Writer)                             { io.WriteString(w, "end\n") }
+func (f *idFormatter) WriteUser(user *api.User, w io.Writer)       { io.WriteString(w, user.Id+"\n") }
+func (f *idFormatter) WriteThread(thread *api.Thread, w io.Writer) { f.WriteItem(&thread.Item, w) }
+
+func TestBuiltinStylesAreRegistered(t *testing.T) {
+	for name, expectedStyle := range map[string]Style{
+		"plain":    Plain,
+		"markdown": Markdown,
+		"md":       Markdown,
+		"json":     Json,
+		"csv":      Csv,
+	} {
+		style, err := ParseStyle(name)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedStyle, style)
+
+		formatter, err := NewFormatter(style, fakeOptions)
+		assert.Nil(t, err)
+		assert.NotNil(t, formatter)
+	}
+}
+
+func TestInvalidStyle(t *testing.T) {
+	_, err := ParseStyle("yaml")
+	assert.EqualError(t, err, "invalid style: yaml\n")
+
+	_, err = NewFormatter(Style("yaml"), fakeOptions)
+	assert.EqualError(t, err, "invalid style: yaml\n")
+}
+
+func TestRegisterStyle(t *testing.T) {
+	style := Style("ids")
+	RegisterStyle(style, func(opts Options) Formatter { return &idFormatter{} }, "id")
+	defer func() {
+		delete(formatters, style)
+		delete(styleNames, "ids")
+		delete(styleNames, "id")
+	}()
+
+	parsed, err := ParseStyle("id")
+	assert.Nil(t, err)
+	assert.Equal(t, style, parsed)
+	assert.Contains(t, StyleNames(), "ids")
+	assert.Contains(t, StyleNames(), "id")
+
+	formatter, err := NewFormatter(parsed, fakeOptions)
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	WriteItems(formatter, []api.Item{job, story}, &output)
+
+	assert.Equal(t, "begin\n1\n2\nend\n", output.String())
+}
+
+func TestRegisterStylePanicsIfTaken(t *testing.T) {
+	assert.Panics(t, func() {
+		RegisterStyle(Style("markdown2"), NewMarkdownFormatter, "md")
+	})
+}
+
+func TestJsonOutputWithoutItems(t *testing.T) {
+	var output bytes.Buffer
+	WriteJson([]api.Item{}, &output)
+
+	assert.Equal(t, "[]\n", output.String())
+}
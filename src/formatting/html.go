@@ -0,0 +1,266 @@
+package formatting
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	hrefRegex           = regexp.MustCompile(`(?i)\bhref\s*=\s*"([^"]*)"`)
+	markdownEscapeRegex = regexp.MustCompile("[\\\\`*_\\[\\]]")
+)
+
+// Converts the HTML subset used by HN in item and user text fields into plain
+// text. Paragraphs are separated by blank lines, code blocks are indented, and
+// links are listed as numbered footnotes at the end.
+func HtmlToText(text string) string {
+	converter := htmlConverter{style: Plain, footnotes: true}
+	return converter.convert(text)
+}
+
+// Converts the HTML subset used by HN in item and user text fields into
+// markdown.
+func HtmlToMarkdown(text string) string {
+	converter := htmlConverter{style: Markdown}
+	return converter.convert(text)
+}
+
+// Converts HN HTML into plain text on a single line, e.g. for titles and
+// previews. Links are reduced to their text.
+func htmlToLine(text string) string {
+	converter := htmlConverter{style: Plain}
+	return strings.Join(strings.Fields(converter.convert(text)), " ")
+}
+
+func escapeMarkdown(text string) string {
+	return markdownEscapeRegex.ReplaceAllString(text, `\$0`)
+}
+
+type htmlConverter struct {
+	style     Style
+	footnotes bool
+
+	out       strings.Builder
+	code      strings.Builder
+	linkText  strings.Builder
+	href      string
+	links     []string
+	inCode    bool
+	inLink    bool
+	needBreak bool
+}
+
+func (c *htmlConverter) convert(text string) string {
+	for len(text) > 0 {
+		start := strings.IndexByte(text, '<')
+		if start < 0 {
+			c.text(text)
+			break
+		}
+		c.text(text[:start])
+		end := strings.IndexByte(text[start:], '>')
+		if end < 0 {
+			c.text(text[start:])
+			break
+		}
+		c.tag(text[start+1 : start+end])
+		text = text[start+end+1:]
+	}
+	if c.inCode {
+		c.endCode()
+	}
+	if c.inLink {
+		c.endLink()
+	}
+
+	if len(c.links) > 0 {
+		c.out.WriteString("\n")
+		for i, link := range c.links {
+			fmt.Fprintf(&c.out, "\n[%d] %s", i+1, link)
+		}
+	}
+	return strings.TrimSpace(c.out.String())
+}
+
+func (c *htmlConverter) tag(tag string) {
+	name, closing := htmlTagName(tag)
+	switch {
+	case name == "p" && !closing:
+		c.needBreak = true
+	case name == "pre" && !closing && !c.inCode:
+		c.needBreak = true
+		c.inCode = true
+	case name == "pre" && closing && c.inCode:
+		c.endCode()
+	case name == "i" && c.style == Markdown && !c.inCode:
+		c.write("*")
+	case name == "a" && !closing && !c.inCode && !c.inLink:
+		c.href = ""
+		if match := hrefRegex.FindStringSubmatch(tag); match != nil {
+			c.href = html.UnescapeString(match[1])
+		}
+		c.inLink = true
+	case name == "a" && closing && c.inLink:
+		c.endLink()
+	}
+}
+
+// Returns the lowercased name of the tag, given its contents between angle
+// brackets, and whether it is a closing tag.
+func htmlTagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	fields := strings.Fields(strings.TrimPrefix(tag, "/"))
+	if len(fields) == 0 {
+		return "", closing
+	}
+	return strings.ToLower(fields[0]), closing
+}
+
+func (c *htmlConverter) text(text string) {
+	if len(text) == 0 {
+		return
+	}
+	text = html.UnescapeString(text)
+	if c.needBreak && !c.inCode && !c.inLink {
+		// Whitespace at the start of a paragraph is insignificant.
+		if text = strings.TrimLeft(text, " \t\n"); len(text) == 0 {
+			return
+		}
+	}
+	switch {
+	case c.inCode:
+		c.code.WriteString(text)
+	case c.style == Markdown:
+		c.write(escapeMarkdown(text))
+	default:
+		c.write(text)
+	}
+}
+
+// Writes inline content, starting a new paragraph first if one is pending.
+func (c *htmlConverter) write(text string) {
+	if c.inLink {
+		c.linkText.WriteString(text)
+		return
+	}
+	if c.needBreak && c.out.Len() > 0 {
+		c.out.WriteString("\n\n")
+	}
+	c.needBreak = false
+	c.out.WriteString(text)
+}
+
+func (c *htmlConverter) endCode() {
+	code := strings.Trim(c.code.String(), "\n")
+	c.code.Reset()
+	c.inCode = false
+
+	var block string
+	switch c.style {
+	case Markdown:
+		block = fmt.Sprintf("```\n%s\n```", code)
+	default:
+		lines := strings.Split(code, "\n")
+		for i := range lines {
+			lines[i] = "    " + lines[i]
+		}
+		block = strings.Join(lines, "\n")
+	}
+	c.needBreak = true
+	c.write(block)
+	c.needBreak = true
+}
+
+func (c *htmlConverter) endLink() {
+	text := c.linkText.String()
+	c.linkText.Reset()
+	c.inLink = false
+
+	switch {
+	case len(c.href) == 0:
+		c.write(text)
+	case c.style == Markdown:
+		c.write(fmt.Sprintf("[%s](%s)", text, c.href))
+	case c.footnotes && text == c.href:
+		c.write(text)
+	case c.footnotes:
+		c.links = append(c.links, c.href)
+		c.write(fmt.Sprintf("%s [%d]", text, len(c.links)))
+	default:
+		c.write(text)
+	}
+}
+
+// Tags that HN uses in item and user text fields, which are all that
+// `SanitizeHtml` keeps.
+var sanitizedTags = map[string]bool{
+	"p":    true,
+	"i":    true,
+	"pre":  true,
+	"code": true,
+	"a":    true,
+}
+
+// Sanitizes HN HTML for embedding in a page. Only the tags HN itself uses are
+// kept, without any of their attributes except for http(s) link targets, and
+// everything else is escaped. Unclosed tags are closed at the end.
+func SanitizeHtml(text string) string {
+	var out strings.Builder
+	var open []string
+	for len(text) > 0 {
+		start := strings.IndexByte(text, '<')
+		end := -1
+		if start >= 0 {
+			end = strings.IndexByte(text[start:], '>')
+		}
+		if start < 0 || end < 0 {
+			out.WriteString(html.EscapeString(html.UnescapeString(text)))
+			break
+		}
+		out.WriteString(html.EscapeString(html.UnescapeString(text[:start])))
+		tag := text[start+1 : start+end]
+		text = text[start+end+1:]
+
+		name, closing := htmlTagName(tag)
+		switch {
+		case !sanitizedTags[name]:
+			out.WriteString(html.EscapeString("<" + tag + ">"))
+		case name == "p" && !closing:
+			// Paragraphs are never closed by HN, so they are left unclosed.
+			out.WriteString("<p>")
+		case name == "p":
+		case !closing && name == "a":
+			href := ""
+			if match := hrefRegex.FindStringSubmatch(tag); match != nil {
+				href = html.UnescapeString(match[1])
+			}
+			if lower := strings.ToLower(href); strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+				fmt.Fprintf(&out, `<a href="%s" rel="nofollow">`, html.EscapeString(href))
+			} else {
+				out.WriteString("<a>")
+			}
+			open = append(open, name)
+		case !closing:
+			fmt.Fprintf(&out, "<%s>", name)
+			open = append(open, name)
+		default:
+			// Only close tags that are open, along with any left open within
+			// them.
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					for len(open) > i {
+						fmt.Fprintf(&out, "</%s>", open[len(open)-1])
+						open = open[:len(open)-1]
+					}
+					break
+				}
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		fmt.Fprintf(&out, "</%s>", open[i])
+	}
+	return out.String()
+}
@@ -0,0 +1,161 @@
+package formatting
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHtmlToText(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			html:     "Just some text",
+			expected: "Just some text",
+		},
+		{
+			name:     "entities",
+			html:     "It&#x27;s &quot;quoted&quot; &amp; 1 &lt; 2 &gt; 0 &#x2F; done",
+			expected: `It's "quoted" & 1 < 2 > 0 / done`,
+		},
+		{
+			name:     "paragraphs",
+			html:     "First paragraph.<p>Second paragraph.<p>Third paragraph.",
+			expected: "First paragraph.\n\nSecond paragraph.\n\nThird paragraph.",
+		},
+		{
+			name:     "closed paragraphs",
+			html:     "<p>First paragraph.</p><p>Second paragraph.</p>",
+			expected: "First paragraph.\n\nSecond paragraph.",
+		},
+		{
+			name:     "italics",
+			html:     "This is <i>important</i>.",
+			expected: "This is important.",
+		},
+		{
+			name:     "links",
+			html:     `See <a href="https:&#x2F;&#x2F;example.com&#x2F;a" rel="nofollow">this page</a> and <a href="https:&#x2F;&#x2F;example.com&#x2F;b">that page</a>.`,
+			expected: "See this page [1] and that page [2].\n\n[1] https://example.com/a\n[2] https://example.com/b",
+		},
+		{
+			name:     "links whose text is the url",
+			html:     `See <a href="https:&#x2F;&#x2F;example.com" rel="nofollow">https:&#x2F;&#x2F;example.com</a>.`,
+			expected: "See https://example.com.",
+		},
+		{
+			name:     "code blocks",
+			html:     "Try this:<p><pre><code>  if x &lt; 1 {\n    return\n  }\n</code></pre>\nIt works.",
+			expected: "Try this:\n\n      if x < 1 {\n        return\n      }\n\nIt works.",
+		},
+		{
+			name:     "unknown tags",
+			html:     "Some <b>bold</b> text",
+			expected: "Some bold text",
+		},
+		{
+			name:     "unclosed tags",
+			html:     "Some text <",
+			expected: "Some text <",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, HtmlToText(test.html))
+		})
+	}
+}
+
+func TestHtmlToMarkdown(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "entities",
+			html:     "It&#x27;s &quot;quoted&quot;",
+			expected: `It's "quoted"`,
+		},
+		{
+			name:     "paragraphs",
+			html:     "First paragraph.<p>Second paragraph.",
+			expected: "First paragraph.\n\nSecond paragraph.",
+		},
+		{
+			name:     "italics",
+			html:     "This is <i>important</i>.",
+			expected: "This is *important*.",
+		},
+		{
+			name:     "links",
+			html:     `See <a href="https:&#x2F;&#x2F;example.com" rel="nofollow">this page</a>.`,
+			expected: "See [this page](https://example.com).",
+		},
+		{
+			name:     "code blocks",
+			html:     "Try this:<p><pre><code>  x := a*b_c\n</code></pre>\nIt works.",
+			expected: "Try this:\n\n```\n  x := a*b_c\n```\n\nIt works.",
+		},
+		{
+			name:     "markdown characters",
+			html:     "2*3 [not a link] snake_case `tick` back\\slash",
+			expected: "2\\*3 \\[not a link\\] snake\\_case \\`tick\\` back\\\\slash",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, HtmlToMarkdown(test.html))
+		})
+	}
+}
+
+func TestHtmlToLine(t *testing.T) {
+	html := `First &amp; <i>second</i>.<p>Third with <a href="https:&#x2F;&#x2F;example.com">a link</a>.`
+
+	assert.Equal(t, "First & second. Third with a link.", htmlToLine(html))
+}
+
+func TestSanitizeHtml(t *testing.T) {
+	for _, test := range []struct {
+		text     string
+		expected string
+	}{
+		{
+			text:     "I&#x27;d say <i>vim</i>.<p>See <a href=\"https:&#x2F;&#x2F;vim.org\" rel=\"nofollow\">vim.org</a>",
+			expected: "I&#39;d say <i>vim</i>.<p>See <a href=\"https://vim.org\" rel=\"nofollow\">vim.org</a>",
+		},
+		{
+			text:     "<pre><code>  if a &lt; b {}\n</code></pre>",
+			expected: "<pre><code>  if a &lt; b {}\n</code></pre>",
+		},
+		{
+			text:     "<script>alert(1)</script><img src=x onerror=alert(1)>",
+			expected: "&lt;script&gt;alert(1)&lt;/script&gt;&lt;img src=x onerror=alert(1)&gt;",
+		},
+		{
+			text:     "<i onclick=\"alert(1)\">hi</i> <a href=\"javascript:alert(1)\">link</a>",
+			expected: "<i>hi</i> <a>link</a>",
+		},
+		{
+			text:     "<a href=\"https://a.com/?q=\"><b>\">x</a>",
+			expected: "<a href=\"https://a.com/?q=\" rel=\"nofollow\">&lt;b&gt;&#34;&gt;x</a>",
+		},
+		{
+			text:     "<i>unclosed <code>tags",
+			expected: "<i>unclosed <code>tags</code></i>",
+		},
+		{
+			text:     "</i>stray <i>close <code>x</i> y</code>",
+			expected: "stray <i>close <code>x</code></i> y",
+		},
+		{
+			text:     "a < b & c <> d",
+			expected: "a &lt; b &amp; c &lt;&gt; d",
+		},
+	} {
+		assert.Equal(t, test.expected, SanitizeHtml(test.text), test.text)
+	}
+}
@@ -0,0 +1,153 @@
+package formatting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+func init() {
+	RegisterStyle(Json, NewJsonFormatter)
+}
+
+// Formats items as an indented json array.
+//
+// Note that we do not do any post-fetch formatting of output here: this is
+// basically a one-to-one mapping of the Item data as fetched. For example, we
+// do not fall back to post urls if the item does not have a url, and the time
+// is represented as the original Unix timestamp instead of the human-readable
+// relative time.
+//
+// If only some fields are asked for, items are written as objects with just
+// those fields, in order.
+type jsonFormatter struct {
+	// The fields to write, or nil to write items as is.
+	fields []itemField
+
+	// Number of items written so far in the current collection.
+	written int
+}
+
+func NewJsonFormatter(opts Options) Formatter {
+	f := &jsonFormatter{}
+	if len(opts.Fields) > 0 {
+		f.fields = opts.itemFields()
+	}
+	return f
+}
+
+func (f *jsonFormatter) Begin(w io.Writer) {
+	f.written = 0
+	fmt.Fprint(w, "[")
+}
+
+func (f *jsonFormatter) WriteItem(item *api.Item, w io.Writer) {
+	f.writeItem(item, nil, w)
+}
+
+// Search results are written as items, along with the story fields of
+// comments.
+func (f *jsonFormatter) WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer) {
+	f.writeItem(item, searchStory(item, result), w)
+}
+
+func (f *jsonFormatter) writeItem(item *api.Item, story *api.Item, w io.Writer) {
+	// Items are indented as they would be within an encoded array.
+	var encoded bytes.Buffer
+	json.Indent(&encoded, f.itemJson(item, story), "\t", "\t")
+	if f.written > 0 {
+		fmt.Fprint(w, ",")
+	}
+	fmt.Fprintf(w, "\n\t%s", encoded.Bytes())
+	f.written++
+}
+
+func (f *jsonFormatter) End(w io.Writer) {
+	if f.written > 0 {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "]")
+}
+
+func (f *jsonFormatter) WriteUser(user *api.User, w io.Writer) {
+	// As with items, this is the user data exactly as fetched.
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(user); err != nil {
+		panic(fmt.Sprintf("error formatting user as json: %s", err.Error()))
+	}
+}
+
+// Writes the user's profile as a json object with their submissions nested
+// under it as a "submissions" array, so that the output is a single document.
+func (f *jsonFormatter) WriteUserSubmissions(user *api.User, submissions []api.Item, w io.Writer) {
+	if submissions == nil {
+		f.WriteUser(user, w)
+		return
+	}
+	userSubmissions := jsonUserSubmissions{User: user, Submissions: make([]json.RawMessage, len(submissions))}
+	for i := range submissions {
+		userSubmissions.Submissions[i] = f.itemJson(&submissions[i], nil)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(userSubmissions); err != nil {
+		panic(fmt.Sprintf("error formatting user as json: %s", err.Error()))
+	}
+}
+
+// A user as fetched along with their submissions, as written by `itemJson`.
+type jsonUserSubmissions struct {
+	*api.User
+	Submissions []json.RawMessage `json:"submissions"`
+}
+
+func (f *jsonFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	// As with items, items are written exactly as fetched, with each item's
+	// replies nested under it.
+	if f.fields != nil {
+		var encoded bytes.Buffer
+		json.Indent(&encoded, threadFieldsJson(thread, f.fields), "", "\t")
+		fmt.Fprintf(w, "%s\n", encoded.Bytes())
+		return
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(thread); err != nil {
+		panic(fmt.Sprintf("error formatting thread as json: %s", err.Error()))
+	}
+}
+
+// Returns the item as a compact json object, i.e. with just the fields asked
+// for, or as fetched along with the story fields if the story it is on is
+// given.
+func (f *jsonFormatter) itemJson(item *api.Item, story *api.Item) []byte {
+	if f.fields != nil {
+		return itemFieldsJson(item, story, f.fields)
+	}
+	marshaled, err := json.Marshal(jsonItem(item, story))
+	if err != nil {
+		panic(fmt.Sprintf("error formatting items as json: %s", err.Error()))
+	}
+	return marshaled
+}
+
+// An item as fetched along with the story fields for the story it is on.
+type jsonItemOnStory struct {
+	*api.Item
+	StoryId    api.ItemId `json:"story_id"`
+	StoryTitle *string    `json:"story_title"`
+	StoryUrl   *string    `json:"story_url"`
+}
+
+// Returns the item as written in json output, i.e. as fetched, along with the
+// story fields if the story it is on is given.
+func jsonItem(item *api.Item, story *api.Item) any {
+	if story == nil {
+		return item
+	}
+	return jsonItemOnStory{item, story.Id, story.Title, story.Url}
+}
@@ -0,0 +1,71 @@
+package formatting
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+func init() {
+	RegisterStyle(Jsonl, NewJsonlFormatter, "ndjson")
+}
+
+// Formats items as JSON Lines, i.e. one compact json object per line.
+//
+// As with json, items are written exactly as fetched. Each line stands on its
+// own, so items can be written as soon as they arrive, and threads are
+// flattened in display order as with csv.
+type jsonlFormatter struct {
+	// The fields to write, or nil to write items as is.
+	fields []itemField
+}
+
+func NewJsonlFormatter(opts Options) Formatter {
+	f := &jsonlFormatter{}
+	if len(opts.Fields) > 0 {
+		f.fields = opts.itemFields()
+	}
+	return f
+}
+
+func (f *jsonlFormatter) Streaming() bool {
+	return true
+}
+
+func (f *jsonlFormatter) Begin(w io.Writer) {}
+
+func (f *jsonlFormatter) End(w io.Writer) {}
+
+func (f *jsonlFormatter) WriteItem(item *api.Item, w io.Writer) {
+	f.writeItem(item, nil, w)
+}
+
+// As with json, search results are written as items, along with the story
+// fields of comments.
+func (f *jsonlFormatter) WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer) {
+	f.writeItem(item, searchStory(item, result), w)
+}
+
+func (f *jsonlFormatter) writeItem(item *api.Item, story *api.Item, w io.Writer) {
+	if f.fields != nil {
+		fmt.Fprintf(w, "%s\n", itemFieldsJson(item, story, f.fields))
+		return
+	}
+	writeJsonLine(jsonItem(item, story), w)
+}
+
+func (f *jsonlFormatter) WriteUser(user *api.User, w io.Writer) {
+	writeJsonLine(user, w)
+}
+
+func (f *jsonlFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	WriteItems(f, flattenThread(thread, nil), w)
+}
+
+func writeJsonLine(v any, w io.Writer) {
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		panic(fmt.Sprintf("error formatting as json lines: %s", err.Error()))
+	}
+}
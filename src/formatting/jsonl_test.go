@@ -0,0 +1,44 @@
+package formatting
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJsonlOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteItems(NewJsonlFormatter(fakeOptions), []api.Item{job, comment}, &output)
+
+	expectedOutput := `{"id":1,"deleted":null,"type":"job","by":"jobuser","time":9978400,"text":null,"dead":null,"parent":null,"poll":null,"kids":null,"url":null,"score":1,"title":"Job title","parts":null,"descendants":null}
+{"id":5,"deleted":null,"type":"comment","by":"commentuser","time":9913600,"text":"Comment text","dead":null,"parent":null,"poll":null,"kids":[6,7,8,9],"url":null,"score":null,"title":null,"parts":null,"descendants":null}
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestJsonlUserOutput(t *testing.T) {
+	var output bytes.Buffer
+	NewJsonlFormatter(fakeOptions).WriteUser(&user, &output)
+
+	expectedOutput := `{"id":"username","created":-41840000,"karma":1234,"about":"About text\nSecond line","submitted":[1,2,3]}
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestJsonlThreadOutput(t *testing.T) {
+	var output bytes.Buffer
+	NewJsonlFormatter(fakeOptions).WriteThread(&thread, &output)
+
+	assert.Equal(t, 4, bytes.Count(output.Bytes(), []byte("\n")))
+	assert.Contains(t, output.String(), `{"id":12,"deleted":null,"type":"comment","by":"replyuser"`)
+}
+
+func TestOnlyJsonlStreams(t *testing.T) {
+	for _, style := range []Style{Plain, Markdown, Json, Jsonl, Csv, Rss, Atom, Html} {
+		formatter, err := NewFormatter(style, fakeOptions)
+		assert.Nil(t, err)
+		assert.Equal(t, style == Jsonl, IsStreaming(formatter), style)
+	}
+}
@@ -0,0 +1,133 @@
+package formatting
+
+import (
+	"fmt"
+	"sort"
+)
+
+// The strings relative times are rendered with, e.g. to translate them.
+// Plurals are format strings taking the rounded up number of units as an int.
+type Locale struct {
+	// For times slightly in the future, i.e. within the clock skew tolerance.
+	JustNow string
+
+	Minute  string
+	Minutes string
+	Hour    string
+	Hours   string
+	Day     string
+	Days    string
+	Month   string
+	Months  string
+	Year    string
+	Years   string
+
+	// For items without a time.
+	Unknown string
+}
+
+var English = &Locale{
+	JustNow: "just now",
+	Minute:  "a minute ago",
+	Minutes: "%d min ago",
+	Hour:    "an hour ago",
+	Hours:   "%d hours ago",
+	Day:     "a day ago",
+	Days:    "%d days ago",
+	Month:   "a month ago",
+	Months:  "%d months ago",
+	Year:    "a year ago",
+	Years:   "%d years ago",
+	Unknown: unknownTimePlaceholder,
+}
+
+// Registered locales by name.
+var locales = map[string]*Locale{}
+
+func init() {
+	RegisterLocale("en", English)
+	RegisterLocale("de", &Locale{
+		JustNow: "gerade eben",
+		Minute:  "vor einer Minute",
+		Minutes: "vor %d Min.",
+		Hour:    "vor einer Stunde",
+		Hours:   "vor %d Stunden",
+		Day:     "vor einem Tag",
+		Days:    "vor %d Tagen",
+		Month:   "vor einem Monat",
+		Months:  "vor %d Monaten",
+		Year:    "vor einem Jahr",
+		Years:   "vor %d Jahren",
+		Unknown: "zu unbekannter Zeit",
+	})
+	RegisterLocale("es", &Locale{
+		JustNow: "justo ahora",
+		Minute:  "hace un minuto",
+		Minutes: "hace %d min",
+		Hour:    "hace una hora",
+		Hours:   "hace %d horas",
+		Day:     "hace un día",
+		Days:    "hace %d días",
+		Month:   "hace un mes",
+		Months:  "hace %d meses",
+		Year:    "hace un año",
+		Years:   "hace %d años",
+		Unknown: "en un momento desconocido",
+	})
+	RegisterLocale("fr", &Locale{
+		JustNow: "à l'instant",
+		Minute:  "il y a une minute",
+		Minutes: "il y a %d min",
+		Hour:    "il y a une heure",
+		Hours:   "il y a %d heures",
+		Day:     "il y a un jour",
+		Days:    "il y a %d jours",
+		Month:   "il y a un mois",
+		Months:  "il y a %d mois",
+		Year:    "il y a un an",
+		Years:   "il y a %d ans",
+		Unknown: "à une date inconnue",
+	})
+	RegisterLocale("it", &Locale{
+		JustNow: "proprio ora",
+		Minute:  "un minuto fa",
+		Minutes: "%d min fa",
+		Hour:    "un'ora fa",
+		Hours:   "%d ore fa",
+		Day:     "un giorno fa",
+		Days:    "%d giorni fa",
+		Month:   "un mese fa",
+		Months:  "%d mesi fa",
+		Year:    "un anno fa",
+		Years:   "%d anni fa",
+		Unknown: "in un momento sconosciuto",
+	})
+}
+
+// Registers a locale so that it can be looked up with `ParseLocale`. Panics if
+// the name is already registered.
+func RegisterLocale(name string, locale *Locale) {
+	if _, ok := locales[name]; ok {
+		panic(fmt.Sprintf("locale already registered: %s", name))
+	}
+	locales[name] = locale
+}
+
+// Returns the registered locale with the given name.
+func ParseLocale(name string) (*Locale, error) {
+	locale, ok := locales[name]
+	if !ok {
+		return nil, fmt.Errorf("invalid locale: %s\n", name)
+	}
+	return locale, nil
+}
+
+// Returns the names of all registered locales, sorted.
+func LocaleNames() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,179 @@
+package formatting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+func init() {
+	RegisterStyle(Markdown, NewMarkdownFormatter, "md")
+}
+
+// Formats items as markdown lists, linking to posts and users.
+type markdownFormatter struct {
+	opts Options
+}
+
+func NewMarkdownFormatter(opts Options) Formatter {
+	return &markdownFormatter{opts: opts}
+}
+
+func (f *markdownFormatter) Begin(w io.Writer) {}
+
+func (f *markdownFormatter) End(w io.Writer) {}
+
+func (f *markdownFormatter) WriteItem(item *api.Item, w io.Writer) {
+	switch item.Type {
+	case api.Job:
+		f.writeJob(item, w)
+	case api.Story:
+		f.writeStory(item, w)
+	case api.Poll:
+		f.writePoll(item, w)
+	case api.PollOpt:
+		f.writePollOpt(item, w)
+	case api.Comment:
+		f.writeComment(item, true, w)
+	default:
+		f.writeUnknown(item, w)
+	}
+}
+
+// As with plain, items of unknown types are written as links to their pages.
+func (f *markdownFormatter) writeUnknown(item *api.Item, w io.Writer) {
+	time := f.opts.itemTime(item)
+	fmt.Fprintf(w, "* **[%s](%s)**\n* └─── by %s %s\n", escapeMarkdown(unknownTypePlaceholder), itemPostUrl(item), itemByLink(item), time)
+}
+
+func (f *markdownFormatter) writeJob(job *api.Item, w io.Writer) {
+	title := itemContent(job, job.Title, markdownLine)
+	time := f.opts.itemTime(job)
+	fmt.Fprintf(w, "* **[HIRING: %s](%s)**\n* └─── %s %s\n", title, itemPostUrl(job), itemPoints(job), time)
+}
+
+func (f *markdownFormatter) writeStory(story *api.Item, w io.Writer) {
+	title := itemContent(story, story.Title, markdownLine)
+	time := f.opts.itemTime(story)
+	fmt.Fprintf(w, "* **[%s](%s)**\n* └─── %s by %s %s | [%s](%s)\n", title, itemUrl(story), itemPoints(story), itemByLink(story), time, itemComments(story), itemPostUrl(story))
+}
+
+func (f *markdownFormatter) writePoll(poll *api.Item, w io.Writer) {
+	title := itemContent(poll, poll.Title, markdownLine)
+	time := f.opts.itemTime(poll)
+	postUrl := itemPostUrl(poll)
+	fmt.Fprintf(w, "* **[%s](%s)**\n* └─── %s by %s %s | [%s](%s)\n", title, postUrl, itemPoints(poll), itemByLink(poll), time, itemComments(poll), postUrl)
+}
+
+func (f *markdownFormatter) writePollOpt(pollopt *api.Item, w io.Writer) {
+	text := f.opts.preview(itemContent(pollopt, pollopt.Text, markdownLine))
+	time := f.opts.itemTime(pollopt)
+	fmt.Fprintf(w, "* **[%s](%s)**\n* └─── %s by %s %s\n", text, itemPostUrl(pollopt), itemPoints(pollopt), itemByLink(pollopt), time)
+}
+
+// Writes a comment, along with how many replies it has if withReplies is set.
+func (f *markdownFormatter) writeComment(comment *api.Item, withReplies bool, w io.Writer) {
+	text := f.opts.preview(itemContent(comment, comment.Text, markdownLine))
+	time := f.opts.itemTime(comment)
+	postUrl := itemPostUrl(comment)
+	fmt.Fprintf(w, "* *[%s](%s)*\n* └─── by %s %s%s\n", text, postUrl, itemByLink(comment), time, markdownReplies(comment, withReplies))
+}
+
+// Like `writeComment`, but along with a link to the story the comment is on.
+func (f *markdownFormatter) writeCommentOnStory(comment *api.Item, story *api.Item, withReplies bool, w io.Writer) {
+	text := f.opts.preview(itemContent(comment, comment.Text, markdownLine))
+	time := f.opts.itemTime(comment)
+	postUrl := itemPostUrl(comment)
+	title := escapeMarkdown(storyTitle(story))
+	fmt.Fprintf(w, "* *[%s](%s)*\n* └─── comment by %s on [%s](%s) %s%s\n", text, postUrl, itemByLink(comment), title, itemPostUrl(story), time, markdownReplies(comment, withReplies))
+}
+
+func markdownReplies(comment *api.Item, withReplies bool) string {
+	if !withReplies {
+		return ""
+	}
+	return fmt.Sprintf(" | [%s](%s)", itemReplies(comment), itemPostUrl(comment))
+}
+
+// Matches are emphasized in bold, except in the bold titles of stories, jobs
+// and polls, where they are italic instead so as not to end the bold early.
+// Urls are only ever link targets, so matches in them are not marked at all.
+func (f *markdownFormatter) WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer) {
+	marked := markSearchMatches(item, result)
+	marked.Url = item.Url
+	var snippetLine string
+	if snippet := searchSnippet(result); len(snippet) > 0 {
+		snippetLine = "* > " + markdownLine(snippet)
+	}
+	writeItem := func(w io.Writer) { f.WriteItem(&marked, w) }
+	if story := searchStory(item, result); story != nil {
+		writeItem = func(w io.Writer) { f.writeCommentOnStory(&marked, story, searchRepliesKnown(item), w) }
+	} else if item.Type == api.Comment {
+		writeItem = func(w io.Writer) { f.writeComment(&marked, searchRepliesKnown(item), w) }
+	}
+	writeSearchResult(italicizeBoldTitleMatches(writeItem), snippetLine, "**", "**", w)
+}
+
+// Returns a writeItem that writes the item as the given one does, but with the
+// matches in its first line rendered in italic if that line is bold.
+func italicizeBoldTitleMatches(writeItem func(w io.Writer)) func(w io.Writer) {
+	return func(w io.Writer) {
+		var buf bytes.Buffer
+		writeItem(&buf)
+		first, rest, _ := strings.Cut(buf.String(), "\n")
+		if strings.HasPrefix(first, "* **") {
+			first = renderMatches(first, "*", "*")
+		}
+		fmt.Fprintf(w, "%s\n%s", first, rest)
+	}
+}
+
+func (f *markdownFormatter) WriteUser(user *api.User, w io.Writer) {
+	about := HtmlToMarkdown(derefStrOr(user.About, ""))
+	time := f.opts.formatTime(time.Unix(user.Created, 0))
+	submissions := countOf(len(user.Submitted), "submission", "submissions")
+
+	fmt.Fprintf(w, "* **[%s](%s%s)**\n", user.Id, userBaseUrl, user.Id)
+	for _, line := range nonEmptyLines(strings.Split(about, "\n")) {
+		fmt.Fprintf(w, "* %s\n", line)
+	}
+	fmt.Fprintf(w, "* └─── %d karma | joined %s | %s\n", user.Karma, time, submissions)
+}
+
+func (f *markdownFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	f.WriteItem(&thread.Item, w)
+	for i := range thread.Replies {
+		f.writeReply(&thread.Replies[i], 1, w)
+	}
+}
+
+func (f *markdownFormatter) writeReply(reply *api.Thread, depth int, w io.Writer) {
+	text := itemContent(&reply.Item, reply.Text, HtmlToMarkdown)
+	time := f.opts.itemTime(&reply.Item)
+	indent := strings.Repeat("  ", depth)
+
+	lines := strings.Split(text, "\n")
+	fmt.Fprintf(w, "%s* %s\n", indent, lines[0])
+	for _, line := range lines[1:] {
+		if len(line) == 0 {
+			fmt.Fprintln(w)
+		} else {
+			fmt.Fprintf(w, "%s  %s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(w, "%s* └─── by %s [%s](%s)\n", indent, itemByLink(&reply.Item), time, itemPostUrl(&reply.Item))
+
+	for i := range reply.Replies {
+		f.writeReply(&reply.Replies[i], depth+1, w)
+	}
+}
+
+// Converts HN HTML for display on a single line of markdown, e.g. for titles
+// and previews.
+func markdownLine(text string) string {
+	return escapeMarkdown(htmlToLine(text))
+}
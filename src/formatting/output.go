@@ -1,8 +1,6 @@
 package formatting
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"io"
 	"strconv"
@@ -15,222 +13,197 @@ import (
 const (
 	itemBaseUrl = "https://news.ycombinator.com/item?id="
 	userBaseUrl = "https://news.ycombinator.com/user?id="
+
+	deletedPlaceholder     = "[deleted]"
+	deadPlaceholder        = "[dead]"
+	unknownTimePlaceholder = "at an unknown time"
+	unknownTypePlaceholder = "[unknown]"
+
+	// Width of comment and poll option previews when there is no output width.
+	defaultPreviewWidth = 80
+
+	// Narrowest that wrapped text gets, no matter how deeply it is indented.
+	minWrapWidth = 20
 )
 
 type Style string
 
+type Options struct {
+	// Used to render relative times.
+	Clock Clock
+
+	// How to render times in plain, markdown and html output. Empty means
+	// relative times.
+	Time TimeFormat
+
+	// Time zone of absolute times. Nil means the default of the time format.
+	Location *time.Location
+
+	// Strings to render relative times with. Nil means English.
+	Locale *Locale
+
+	// Max width of plain output lines, in terminal columns, past which titles
+	// and urls are truncated and longer text is wrapped. Zero means no limit.
+	Width int
+
+	// Names of the item fields to include in csv, tsv and json output, in
+	// order. Empty means all the fields of the item as fetched.
+	Fields []string
+
+	// If true, csv and tsv output starts with a header row of column names.
+	Header bool
+
+	// If true, plain output emphasizes the terms that matched searches in bold
+	// with ANSI escape codes, e.g. when written to a terminal.
+	Ansi bool
+}
+
 const (
 	Plain    Style = "plain"
-	Markdown       = "markdown"
-	Json           = "json"
-	Csv            = "csv"
+	Markdown Style = "markdown"
+	Json     Style = "json"
+	Jsonl    Style = "jsonl"
+	Csv      Style = "csv"
+	Tsv      Style = "tsv"
+	Rss      Style = "rss"
+	Atom     Style = "atom"
+	Html     Style = "html"
 )
 
-func WritePlain(items []api.Item, clock Clock, w io.Writer) {
-	writeItems(items, Plain, clock, w)
+func WritePlain(items []api.Item, opts Options, w io.Writer) {
+	WriteItems(NewPlainFormatter(opts), items, w)
 }
 
-func WriteMarkdown(items []api.Item, clock Clock, w io.Writer) {
-	writeItems(items, Markdown, clock, w)
+func WriteMarkdown(items []api.Item, opts Options, w io.Writer) {
+	WriteItems(NewMarkdownFormatter(opts), items, w)
 }
 
 func WriteJson(items []api.Item, w io.Writer) {
-	// Note that we do not do any post-fetch formatting of output here: this is
-	// basically a one-to-one mapping of the Item data as fetched. For example,
-	// we do not fall back to post urls if the item does not have a url, and the
-	// time is represented as the original Unix timestamp instead of the human-
-	// readable relative time.
-	encoder := json.NewEncoder(w)
-	encoder.SetIndent("", "\t")
-	if err := encoder.Encode(items); err != nil {
-		panic(fmt.Sprintf("error formatting items as json: %s", err.Error()))
-	}
+	WriteItems(NewJsonFormatter(Options{}), items, w)
 }
 
 func WriteCsv(items []api.Item, w io.Writer) {
-	// Similar to `WriteJson`, this is a "raw" fetch of data without any
-	// additional post-processing.
-	records := make([][]string, len(items))
-	for i, item := range items {
-		records[i] = []string{
-			intToStr(item.Id),
-			derefBoolOrEmptyStr(item.Deleted),
-			string(item.Type),
-			derefStrOr(item.By, ""),
-			derefIntOr(item.Time, 0),
-			derefStrOr(item.Text, ""),
-			derefBoolOrEmptyStr(item.Dead),
-			derefIntOr(item.Parent, 0),
-			derefIntOr(item.Poll, 0),
-			idsToStr(item.Kids),
-			derefStrOr(item.Url, ""),
-			derefIntOr(item.Score, 0),
-			derefStrOr(item.Title, ""),
-			idsToStr(item.Parts),
-			derefIntOr(item.Descendants, 0),
-		}
-	}
-	csvWriter := csv.NewWriter(w)
-	if err := csvWriter.WriteAll(records); err != nil {
-		panic(fmt.Sprintf("error formatting items as csv: %s", err.Error()))
-	}
+	WriteItems(NewCsvFormatter(Options{}), items, w)
 }
 
-func writeItems(items []api.Item, style Style, clock Clock, w io.Writer) {
-	for _, item := range items {
-		switch item.Type {
-		case api.Job:
-			writeJobItem(&item, style, clock, w)
-		case api.Story:
-			writeStoryItem(&item, style, clock, w)
-		case api.Poll:
-			writePollItem(&item, style, clock, w)
-		case api.PollOpt:
-			writePollOptItem(&item, style, clock, w)
-		case api.Comment:
-			writeCommentItem(&item, style, clock, w)
-		default:
-			panic(fmt.Sprintf("invalid item type %s", item.Type))
-		}
+// Shortens a line of plain output to fit the output width, if there is one.
+func (opts *Options) fit(line string) string {
+	if opts.Width > 0 {
+		return Truncate(line, opts.Width)
 	}
+	return line
 }
 
-func writeJobItem(job *api.Item, style Style, clock Clock, w io.Writer) {
-	score := *job.Score
-	postUrl := fmt.Sprintf("%s%d", itemBaseUrl, job.Id)
-	title := *job.Title
-	time := GetRelativeTime(clock, time.Unix(*job.Time, 0))
-	ptsstr := "pts"
-
-	if score == 1 {
-		ptsstr = "pt"
-	}
-
-	switch style {
-	case Plain:
-		fmt.Fprintf(w, "HIRING: %s\n└─── %d %s %s\n", postUrl, score, ptsstr, time)
-	case Markdown:
-		fmt.Fprintf(w, "* **[HIRING: %s](%s)**\n* └─── %d %s %s\n", title, postUrl, score, ptsstr, time)
-	default:
-		panic(fmt.Sprintf("invalid style: %s\n", style))
+// Shortens text to a one-line preview.
+func (opts *Options) preview(text string) string {
+	if opts.Width > 0 {
+		return Truncate(text, opts.Width)
 	}
+	return Truncate(text, defaultPreviewWidth)
 }
 
-func writeStoryItem(story *api.Item, style Style, clock Clock, w io.Writer) {
-	by := *story.By
-	score := *story.Score
-	comments := *story.Descendants
-	postUrl := fmt.Sprintf("%s%d", itemBaseUrl, story.Id)
-	byUrl := fmt.Sprintf("%s%s", userBaseUrl, by)
-	title := *story.Title
-	url := postUrl
-	time := GetRelativeTime(clock, time.Unix(*story.Time, 0))
-	ptsstr := "pts"
-	commentsstr := "comments"
-
-	if story.Url != nil && len(*story.Url) > 0 {
-		url = *story.Url
+// Wraps each line of the text to fit the output width, if there is one, once
+// indented by the given number of columns. Lines indented as code blocks are
+// kept as is.
+func (opts *Options) wrap(text string, indent int) []string {
+	lines := strings.Split(text, "\n")
+	if opts.Width <= 0 {
+		return lines
+	}
+	width := max(opts.Width-indent, minWrapWidth)
+	var wrapped []string
+	for _, line := range lines {
+		if len(line) == 0 || strings.HasPrefix(line, "    ") {
+			wrapped = append(wrapped, line)
+		} else {
+			wrapped = append(wrapped, Wrap(line, width)...)
+		}
 	}
+	return wrapped
+}
 
-	if score == 1 {
-		ptsstr = "pt"
-	}
-	if comments == 1 {
-		commentsstr = "comment"
+// Returns the given HTML content of the item, e.g. its title or text, converted
+// for display and marked or replaced by a placeholder if the item is dead or
+// deleted.
+func itemContent(item *api.Item, content *string, convert func(string) string) string {
+	switch {
+	case item.IsDeleted():
+		return deletedPlaceholder
+	case item.IsDead() && content != nil:
+		return fmt.Sprintf("%s %s", deadPlaceholder, convert(*content))
+	case item.IsDead():
+		return deadPlaceholder
+	case content == nil:
+		return deletedPlaceholder
+	default:
+		return convert(*content)
 	}
+}
 
-	switch style {
-	case Plain:
-		fmt.Fprintf(w, "%s\n└─── %d %s by %s %s | %d %s\n", url, score, ptsstr, by, time, comments, commentsstr)
-	case Markdown:
-		fmt.Fprintf(w, "* **[%s](%s)**\n* └─── %d %s by [%s](%s) %s | [%d %s](%s)\n", title, url, score, ptsstr, by, byUrl, time, comments, commentsstr, postUrl)
+// Returns the placeholder that marks an item as deleted or dead, followed by a
+// space, or nothing if it is neither. For lines that show something other than
+// the item's content, e.g. its url, which deleted and dead items still have.
+func itemStatusPrefix(item *api.Item) string {
+	switch {
+	case item.IsDeleted():
+		return deletedPlaceholder + " "
+	case item.IsDead():
+		return deadPlaceholder + " "
 	default:
-		panic(fmt.Sprintf("invalid style: %s\n", style))
+		return ""
 	}
 }
 
-func writePollItem(poll *api.Item, style Style, clock Clock, w io.Writer) {
-	by := *poll.By
-	score := *poll.Score
-	comments := *poll.Descendants
-	postUrl := fmt.Sprintf("%s%d", itemBaseUrl, poll.Id)
-	byUrl := fmt.Sprintf("%s%s", userBaseUrl, by)
-	title := *poll.Title
-	time := GetRelativeTime(clock, time.Unix(*poll.Time, 0))
-	ptsstr := "pts"
-	commentsstr := "comments"
-
-	if score == 1 {
-		ptsstr = "pt"
-	}
-	if comments == 1 {
-		commentsstr = "comment"
-	}
+func itemPostUrl(item *api.Item) string {
+	return fmt.Sprintf("%s%d", itemBaseUrl, item.Id)
+}
 
-	switch style {
-	case Plain:
-		fmt.Fprintf(w, "%s\n└─── %d %s by %s %s | %d %s\n", postUrl, score, ptsstr, by, time, comments, commentsstr)
-	case Markdown:
-		fmt.Fprintf(w, "* **[%s](%s)**\n* └─── %d %s by [%s](%s) %s | [%d %s](%s)\n", title, postUrl, score, ptsstr, by, byUrl, time, comments, commentsstr, postUrl)
-	default:
-		panic(fmt.Sprintf("invalid style: %s\n", style))
+// Returns the item's url, falling back to its post url if it has none, e.g. for
+// Ask HN stories.
+func itemUrl(item *api.Item) string {
+	if item.Url != nil && len(*item.Url) > 0 {
+		return *item.Url
 	}
+	return itemPostUrl(item)
 }
 
-func writePollOptItem(pollopt *api.Item, style Style, clock Clock, w io.Writer) {
-	by := *pollopt.By
-	postUrl := fmt.Sprintf("%s%d", itemBaseUrl, pollopt.Id)
-	byUrl := fmt.Sprintf("%s%s", userBaseUrl, by)
-	text := *pollopt.Text
-	time := GetRelativeTime(clock, time.Unix(*pollopt.Time, 0))
-	score := *pollopt.Score
-	ptsstr := "pts"
-
-	// TODO: This is quite hacky but will do for now while we overhaul the UI.
-	if len(text) > 70 {
-		text = fmt.Sprintf("%s...", text[:70])
-	}
+func itemPoints(item *api.Item) string {
+	return countOf(derefOr(item.Score, 0), "pt", "pts")
+}
 
-	if score == 1 {
-		ptsstr = "pt"
-	}
+func itemComments(item *api.Item) string {
+	return countOf(derefOr(item.Descendants, 0), "comment", "comments")
+}
 
-	switch style {
-	case Plain:
-		fmt.Fprintf(w, "%s\n└─── %d %s by %s %s\n", text, score, ptsstr, by, time)
-	case Markdown:
-		fmt.Fprintf(w, "* **[%s](%s)**\n* └─── %d %s by [%s](%s) %s\n", text, postUrl, score, ptsstr, by, byUrl, time)
-	default:
-		panic(fmt.Sprintf("invalid style: %s\n", style))
-	}
+func itemReplies(item *api.Item) string {
+	return countOf(len(item.Kids), "reply", "replies")
 }
 
-func writeCommentItem(comment *api.Item, style Style, clock Clock, w io.Writer) {
-	by := *comment.By
-	postUrl := fmt.Sprintf("%s%d", itemBaseUrl, comment.Id)
-	byUrl := fmt.Sprintf("%s%s", userBaseUrl, by)
-	text := *comment.Text
-	time := GetRelativeTime(clock, time.Unix(*comment.Time, 0))
-	comments := len(comment.Kids)
-	repliesstr := "replies"
-
-	// TODO: This is quite hacky but will do for now while we overhaul the UI.
-	if len(text) > 70 {
-		text = fmt.Sprintf("%s...", text[:70])
+// Returns the count along with the singular or plural noun, e.g. "1 pt".
+func countOf[Int int | int32](n Int, singular string, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
 	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+func itemBy(item *api.Item) string {
+	return derefStrOr(item.By, deletedPlaceholder)
+}
 
-	if comments == 1 {
-		repliesstr = "reply"
+// Returns the item's author as a markdown link to their profile, if known.
+func itemByLink(item *api.Item) string {
+	if item.By == nil {
+		return deletedPlaceholder
 	}
+	return fmt.Sprintf("[%s](%s%s)", *item.By, userBaseUrl, *item.By)
+}
 
-	switch style {
-	case Plain:
-		fmt.Fprintf(w, "%s\n└─── by %s %s | %d %s\n", text, by, time, comments, repliesstr)
-	case Markdown:
-		fmt.Fprintf(w, "* *[%s](%s)*\n* └─── by [%s](%s) %s | [%d %s](%s)\n", text, postUrl, by, byUrl, time, comments, repliesstr, postUrl)
-	default:
-		panic(fmt.Sprintf("invalid style: %s\n", style))
+func derefOr[T any](ptr *T, or T) T {
+	if ptr != nil {
+		return *ptr
 	}
+	return or
 }
 
 func derefStrOr(ptr *string, or string) string {
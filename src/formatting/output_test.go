@@ -2,6 +2,7 @@ package formatting
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,7 +11,7 @@ import (
 )
 
 type Addressable interface {
-	int32 | int64 | string
+	bool | int32 | int64 | string
 }
 
 func ptr[T Addressable](t T) *T {
@@ -22,8 +23,9 @@ func intptr(i int) *int32 {
 }
 
 var (
-	now       = time.Unix(10000000, 0)
-	fakeClock = FakeClock{now}
+	now         = time.Unix(10000000, 0)
+	fakeClock   = FakeClock{now}
+	fakeOptions = Options{Clock: &fakeClock}
 
 	job = api.Item{
 		Id:    1,
@@ -77,11 +79,11 @@ var (
 func TestPlainOutput(t *testing.T) {
 	var jobOutput, storyOutput, pollOutput, pollOptOutput, commentOutput bytes.Buffer
 
-	writeJobItem(&job, Plain, &fakeClock, &jobOutput)
-	writeStoryItem(&story, Plain, &fakeClock, &storyOutput)
-	writePollItem(&poll, Plain, &fakeClock, &pollOutput)
-	writePollOptItem(&pollopt, Plain, &fakeClock, &pollOptOutput)
-	writeCommentItem(&comment, Plain, &fakeClock, &commentOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&job, &jobOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&story, &storyOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&poll, &pollOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&pollopt, &pollOptOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&comment, &commentOutput)
 
 	expectedJobOutput := "HIRING: https://news.ycombinator.com/item?id=1\n└─── 1 pt 6 hours ago\n"
 	expectedStoryOutput := "www.story.url\n└─── 10 pts by storyuser 12 days ago | 20 comments\n"
@@ -99,11 +101,11 @@ func TestPlainOutput(t *testing.T) {
 func TestMarkdownOutput(t *testing.T) {
 	var jobOutput, storyOutput, pollOutput, pollOptOutput, commentOutput bytes.Buffer
 
-	writeJobItem(&job, Markdown, &fakeClock, &jobOutput)
-	writeStoryItem(&story, Markdown, &fakeClock, &storyOutput)
-	writePollItem(&poll, Markdown, &fakeClock, &pollOutput)
-	writePollOptItem(&pollopt, Markdown, &fakeClock, &pollOptOutput)
-	writeCommentItem(&comment, Markdown, &fakeClock, &commentOutput)
+	NewMarkdownFormatter(fakeOptions).WriteItem(&job, &jobOutput)
+	NewMarkdownFormatter(fakeOptions).WriteItem(&story, &storyOutput)
+	NewMarkdownFormatter(fakeOptions).WriteItem(&poll, &pollOutput)
+	NewMarkdownFormatter(fakeOptions).WriteItem(&pollopt, &pollOptOutput)
+	NewMarkdownFormatter(fakeOptions).WriteItem(&comment, &commentOutput)
 
 	expectedJobOutput := "* **[HIRING: Job title](https://news.ycombinator.com/item?id=1)**\n* └─── 1 pt 6 hours ago\n"
 	expectedStoryOutput := "* **[Story title](www.story.url)**\n* └─── 10 pts by [storyuser](https://news.ycombinator.com/user?id=storyuser) 12 days ago | [20 comments](https://news.ycombinator.com/item?id=2)\n"
@@ -235,6 +237,7 @@ func TestCsvOutput(t *testing.T) {
 func TestStoryWithoutUrlFallbackToPostUrl(t *testing.T) {
 	story := api.Item{
 		Id:          2,
+		Type:        api.Story,
 		Score:       intptr(10),
 		By:          ptr("storyuser"),
 		Time:        ptr(now.Add(-12 * 24 * time.Hour).Unix()), // 12 days ago
@@ -243,7 +246,7 @@ func TestStoryWithoutUrlFallbackToPostUrl(t *testing.T) {
 	}
 
 	var storyOutput bytes.Buffer
-	writeStoryItem(&story, Plain, &fakeClock, &storyOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&story, &storyOutput)
 
 	expectedStoryOutput := "https://news.ycombinator.com/item?id=2\n└─── 10 pts by storyuser 12 days ago | 20 comments\n"
 
@@ -263,11 +266,11 @@ func TestSingularOutput(t *testing.T) {
 
 	var jobOutput, storyOutput, pollOutput, pollOptOutput, commentOutput bytes.Buffer
 
-	writeJobItem(&job, Plain, &fakeClock, &jobOutput)
-	writeStoryItem(&story, Plain, &fakeClock, &storyOutput)
-	writePollItem(&poll, Plain, &fakeClock, &pollOutput)
-	writePollOptItem(&pollopt, Plain, &fakeClock, &pollOptOutput)
-	writeCommentItem(&comment, Plain, &fakeClock, &commentOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&job, &jobOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&story, &storyOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&poll, &pollOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&pollopt, &pollOptOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&comment, &commentOutput)
 
 	expectedJobOutput := "HIRING: https://news.ycombinator.com/item?id=1\n└─── 1 pt 6 hours ago\n"
 	expectedStoryOutput := "www.story.url\n└─── 1 pt by storyuser 12 days ago | 1 comment\n"
@@ -281,3 +284,216 @@ func TestSingularOutput(t *testing.T) {
 	assert.Equal(t, expectedpollOptOutput, pollOptOutput.String())
 	assert.Equal(t, expectedcommentOutput, commentOutput.String())
 }
+
+func TestPlainOutputWithTimeOptions(t *testing.T) {
+	var isoOutput, unixOutput, localeOutput, unknownOutput bytes.Buffer
+
+	timeless := comment
+	timeless.Time = nil
+
+	NewPlainFormatter(Options{Clock: &fakeClock, Time: IsoTime}).WriteItem(&story, &isoOutput)
+	NewPlainFormatter(Options{Clock: &fakeClock, Time: UnixTime}).WriteItem(&story, &unixOutput)
+	NewPlainFormatter(Options{Clock: &fakeClock, Locale: locales["es"]}).WriteItem(&story, &localeOutput)
+	NewPlainFormatter(Options{Clock: &fakeClock, Locale: locales["es"]}).WriteItem(&timeless, &unknownOutput)
+
+	assert.Equal(t, "www.story.url\n└─── 10 pts by storyuser 1970-04-14T17:46:40Z | 20 comments\n", isoOutput.String())
+	assert.Equal(t, "www.story.url\n└─── 10 pts by storyuser 8963200 | 20 comments\n", unixOutput.String())
+	assert.Equal(t, "www.story.url\n└─── 10 pts by storyuser hace 12 días | 20 comments\n", localeOutput.String())
+	assert.Equal(t, "Comment text\n└─── by commentuser en un momento desconocido | 4 replies\n", unknownOutput.String())
+}
+
+func TestOutputDoesNotPanicForAnyMissingFields(t *testing.T) {
+	// Every combination of the optional fields that the writers read.
+	clearers := []func(*api.Item){
+		func(item *api.Item) { item.By = nil },
+		func(item *api.Item) { item.Score = nil },
+		func(item *api.Item) { item.Descendants = nil },
+		func(item *api.Item) { item.Title = nil },
+		func(item *api.Item) { item.Text = nil },
+		func(item *api.Item) { item.Time = nil },
+		func(item *api.Item) { item.Url = nil },
+		func(item *api.Item) { item.Kids = nil },
+	}
+	for _, base := range []api.Item{job, story, poll, pollopt, comment} {
+		for mask := 0; mask < 1<<len(clearers); mask++ {
+			item := base
+			for i, clear := range clearers {
+				if mask&(1<<i) != 0 {
+					clear(&item)
+				}
+			}
+			for _, style := range []Style{Plain, Markdown, Json, Csv} {
+				assert.NotPanics(t, func() {
+					var output bytes.Buffer
+					formatter, _ := NewFormatter(style, fakeOptions)
+					WriteItems(formatter, []api.Item{item}, &output)
+				}, "type %s, style %s, mask %b", item.Type, style, mask)
+			}
+		}
+	}
+}
+
+func TestOutputForItemsOfUnknownType(t *testing.T) {
+	var plainOutput, markdownOutput bytes.Buffer
+
+	unknown := api.Item{Id: 10}
+	NewPlainFormatter(fakeOptions).WriteItem(&unknown, &plainOutput)
+	NewMarkdownFormatter(fakeOptions).WriteItem(&unknown, &markdownOutput)
+
+	assert.Equal(t, "[unknown] https://news.ycombinator.com/item?id=10\n└─── by [deleted] at an unknown time\n", plainOutput.String())
+	assert.Equal(t, "* **[\\[unknown\\]](https://news.ycombinator.com/item?id=10)**\n* └─── by [deleted] at an unknown time\n", markdownOutput.String())
+}
+
+func TestOutputForDeletedAndDeadItems(t *testing.T) {
+	deleted := func(item api.Item) api.Item {
+		// Deleted items only keep their id, type, time, and relationships.
+		return api.Item{
+			Id:      item.Id,
+			Type:    item.Type,
+			Time:    item.Time,
+			Kids:    item.Kids,
+			Deleted: ptr(true),
+		}
+	}
+	dead := func(item api.Item) api.Item {
+		item.Dead = ptr(true)
+		return item
+	}
+	missing := func(item api.Item) api.Item {
+		return api.Item{Id: item.Id, Type: item.Type}
+	}
+
+	for _, test := range []struct {
+		name     string
+		item     api.Item
+		plain    string
+		markdown string
+	}{
+		{
+			name:     "deleted job",
+			item:     deleted(job),
+			plain:    "[deleted] HIRING: https://news.ycombinator.com/item?id=1\n└─── 0 pts 6 hours ago\n",
+			markdown: "* **[HIRING: [deleted]](https://news.ycombinator.com/item?id=1)**\n* └─── 0 pts 6 hours ago\n",
+		},
+		{
+			name:     "deleted story",
+			item:     deleted(story),
+			plain:    "[deleted] https://news.ycombinator.com/item?id=2\n└─── 0 pts by [deleted] 12 days ago | 0 comments\n",
+			markdown: "* **[[deleted]](https://news.ycombinator.com/item?id=2)**\n* └─── 0 pts by [deleted] 12 days ago | [0 comments](https://news.ycombinator.com/item?id=2)\n",
+		},
+		{
+			name:     "deleted poll",
+			item:     deleted(poll),
+			plain:    "[deleted] https://news.ycombinator.com/item?id=3\n└─── 0 pts by [deleted] 40 min ago | 0 comments\n",
+			markdown: "* **[[deleted]](https://news.ycombinator.com/item?id=3)**\n* └─── 0 pts by [deleted] 40 min ago | [0 comments](https://news.ycombinator.com/item?id=3)\n",
+		},
+		{
+			name:     "deleted pollopt",
+			item:     deleted(pollopt),
+			plain:    "[deleted]\n└─── 0 pts by [deleted] 3 months ago\n",
+			markdown: "* **[[deleted]](https://news.ycombinator.com/item?id=4)**\n* └─── 0 pts by [deleted] 3 months ago\n",
+		},
+		{
+			name:     "deleted comment",
+			item:     deleted(comment),
+			plain:    "[deleted]\n└─── by [deleted] a day ago | 4 replies\n",
+			markdown: "* *[[deleted]](https://news.ycombinator.com/item?id=5)*\n* └─── by [deleted] a day ago | [4 replies](https://news.ycombinator.com/item?id=5)\n",
+		},
+		{
+			name:     "dead story",
+			item:     dead(story),
+			plain:    "[dead] www.story.url\n└─── 10 pts by storyuser 12 days ago | 20 comments\n",
+			markdown: "* **[[dead] Story title](www.story.url)**\n* └─── 10 pts by [storyuser](https://news.ycombinator.com/user?id=storyuser) 12 days ago | [20 comments](https://news.ycombinator.com/item?id=2)\n",
+		},
+		{
+			name:     "dead job",
+			item:     dead(job),
+			plain:    "[dead] HIRING: https://news.ycombinator.com/item?id=1\n└─── 1 pt 6 hours ago\n",
+			markdown: "* **[HIRING: [dead] Job title](https://news.ycombinator.com/item?id=1)**\n* └─── 1 pt 6 hours ago\n",
+		},
+		{
+			name:     "dead poll",
+			item:     dead(poll),
+			plain:    "[dead] https://news.ycombinator.com/item?id=3\n└─── 100 pts by polluser 40 min ago | 200 comments\n",
+			markdown: "* **[[dead] Poll title](https://news.ycombinator.com/item?id=3)**\n* └─── 100 pts by [polluser](https://news.ycombinator.com/user?id=polluser) 40 min ago | [200 comments](https://news.ycombinator.com/item?id=3)\n",
+		},
+		{
+			name:     "dead comment",
+			item:     dead(comment),
+			plain:    "[dead] Comment text\n└─── by commentuser a day ago | 4 replies\n",
+			markdown: "* *[[dead] Comment text](https://news.ycombinator.com/item?id=5)*\n* └─── by [commentuser](https://news.ycombinator.com/user?id=commentuser) a day ago | [4 replies](https://news.ycombinator.com/item?id=5)\n",
+		},
+		{
+			name:     "dead comment without text",
+			item:     dead(missing(comment)),
+			plain:    "[dead]\n└─── by [deleted] at an unknown time | 0 replies\n",
+			markdown: "* *[[dead]](https://news.ycombinator.com/item?id=5)*\n* └─── by [deleted] at an unknown time | [0 replies](https://news.ycombinator.com/item?id=5)\n",
+		},
+		{
+			name:     "story missing all fields",
+			item:     missing(story),
+			plain:    "https://news.ycombinator.com/item?id=2\n└─── 0 pts by [deleted] at an unknown time | 0 comments\n",
+			markdown: "* **[[deleted]](https://news.ycombinator.com/item?id=2)**\n* └─── 0 pts by [deleted] at an unknown time | [0 comments](https://news.ycombinator.com/item?id=2)\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var plainOutput, markdownOutput bytes.Buffer
+
+			WritePlain([]api.Item{test.item}, fakeOptions, &plainOutput)
+			WriteMarkdown([]api.Item{test.item}, fakeOptions, &markdownOutput)
+
+			assert.Equal(t, test.plain, plainOutput.String())
+			assert.Equal(t, test.markdown, markdownOutput.String())
+		})
+	}
+}
+
+func TestOutputConvertsHtml(t *testing.T) {
+	story, comment := story, comment
+	story.Title = ptr("Ask HN: What&#x27;s *your* [favorite] editor?")
+	comment.Text = ptr(`I&#x27;d say <i>vim</i>.<p>See <a href="https:&#x2F;&#x2F;vim.org">vim.org</a>`)
+
+	var plainOutput, markdownOutput bytes.Buffer
+	WritePlain([]api.Item{story, comment}, fakeOptions, &plainOutput)
+	WriteMarkdown([]api.Item{story, comment}, fakeOptions, &markdownOutput)
+
+	expectedPlainOutput := "www.story.url\n└─── 10 pts by storyuser 12 days ago | 20 comments\n" +
+		"I'd say vim. See vim.org\n└─── by commentuser a day ago | 4 replies\n"
+	expectedMarkdownOutput := "* **[Ask HN: What's \\*your\\* \\[favorite\\] editor?](www.story.url)**\n* └─── 10 pts by [storyuser](https://news.ycombinator.com/user?id=storyuser) 12 days ago | [20 comments](https://news.ycombinator.com/item?id=2)\n" +
+		"* *[I'd say vim. See vim.org](https://news.ycombinator.com/item?id=5)*\n* └─── by [commentuser](https://news.ycombinator.com/user?id=commentuser) a day ago | [4 replies](https://news.ycombinator.com/item?id=5)\n"
+
+	assert.Equal(t, expectedPlainOutput, plainOutput.String())
+	assert.Equal(t, expectedMarkdownOutput, markdownOutput.String())
+}
+
+func TestPreviewsAreTruncatedWithoutSplittingCharacters(t *testing.T) {
+	comment, pollopt := comment, pollopt
+	comment.Text = ptr(strings.Repeat("日本語のテキスト", 10))
+	pollopt.Text = ptr(strings.Repeat("Zoë 👍🏽 ", 20))
+
+	var commentOutput, pollOptOutput bytes.Buffer
+	NewPlainFormatter(fakeOptions).WriteItem(&comment, &commentOutput)
+	NewPlainFormatter(fakeOptions).WriteItem(&pollopt, &pollOptOutput)
+
+	expectedCommentOutput := strings.Repeat("日本語のテキスト", 4) + "日本語のテキ...\n└─── by commentuser a day ago | 4 replies\n"
+	expectedPollOptOutput := strings.Repeat("Zoë 👍🏽 ", 11) + "...\n└─── 1000 pts by polloptuser 3 months ago\n"
+
+	assert.Equal(t, expectedCommentOutput, commentOutput.String())
+	assert.Equal(t, expectedPollOptOutput, pollOptOutput.String())
+}
+
+func TestPlainOutputFitsWidth(t *testing.T) {
+	job, story, comment := job, story, comment
+	story.Url = ptr("https://例え.jp/とても/長い/パス")
+	comment.Text = ptr("Ça marche très bien, merci beaucoup")
+	opts := Options{Clock: &fakeClock, Width: 20}
+
+	var output bytes.Buffer
+	WritePlain([]api.Item{job, story, comment}, opts, &output)
+
+	expectedOutput := "HIRING: https://n...\n└─── 1 pt 6 hours ago\n" +
+		"https://例え.jp/...\n└─── 10 pts by storyuser 12 days ago | 20 comments\n" +
+		"Ça marche très bi...\n└─── by commentuser a day ago | 4 replies\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
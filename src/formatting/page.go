@@ -0,0 +1,270 @@
+package formatting
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+func init() {
+	RegisterStyle(Html, NewHtmlFormatter)
+}
+
+// Pixels that each level of a comment tree is indented by, as on HN.
+const htmlCommentIndent = 40
+
+var htmlTemplates = template.Must(template.New("page").Parse(`
+{{- define "head" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Hacker News</title>
+<style>
+body { margin: 8px auto; width: 85%; min-width: 320px; background: #f6f6ef; color: #000; font-family: Verdana, Geneva, sans-serif; font-size: 10pt; }
+a { color: #000; text-decoration: none; }
+a:hover { text-decoration: underline; }
+.header { background: #ff6600; padding: 4px 6px; }
+.header a { font-weight: bold; }
+.items { border-spacing: 0; padding: 6px 0; }
+.items td { padding: 0 2px; vertical-align: top; }
+.rank { color: #828282; text-align: right; }
+.title { font-size: 10pt; }
+.sitestr, .subtext, .subtext a, .comhead, .comhead a { color: #828282; font-size: 7pt; }
+.subtext { padding-bottom: 5px !important; }
+.comment, .profile { padding: 6px 0; }
+.commtext { font-size: 9pt; overflow-wrap: anywhere; }
+.commtext a { color: #000; text-decoration: underline; }
+pre { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<div class="header"><a href="https://news.ycombinator.com/">Hacker News</a></div>
+{{end}}
+
+{{- define "item" -}}
+<tr><td class="rank">{{if .Rank}}{{.Rank}}.{{end}}</td><td class="title">
+{{- if .Text}}<div class="commtext">{{.Text}}</div>
+{{- else}}<a href="{{.Url}}">{{.Title}}</a>{{with .Domain}} <span class="sitestr">({{.}})</span>{{end}}{{end -}}
+</td></tr>
+<tr><td></td><td class="subtext">{{template "subtext" .}}</td></tr>
+{{end}}
+
+{{- define "subtext" -}}
+{{with .Points}}{{.}} {{end}}
+{{- with .By}}by {{template "by" $}} {{end -}}
+<a href="{{.PostUrl}}">{{.Time}}</a>
+{{- with .Comments}} | <a href="{{$.PostUrl}}">{{.}}</a>{{end}}
+{{- with .Story}} | on: <a href="{{$.StoryUrl}}">{{.}}</a>{{end}}
+{{- end}}
+
+{{- define "by" -}}
+{{if .ByUrl}}<a href="{{.ByUrl}}">{{.By}}</a>{{else}}{{.By}}{{end}}
+{{- end}}
+
+{{- define "reply" -}}
+<div class="comment" style="margin-left: {{.Indent}}px">
+<div class="comhead">{{template "by" .}} <a href="{{.PostUrl}}">{{.Time}}</a></div>
+<div class="commtext">{{.Text}}</div>
+</div>
+{{end}}
+
+{{- define "user" -}}
+<div class="profile">
+<div class="title"><a href="{{.ByUrl}}">{{.By}}</a></div>
+{{with .Text}}<div class="commtext">{{.}}</div>
+{{end -}}
+<div class="subtext">{{.Points}} | joined {{.Time}} | {{.Comments}}</div>
+</div>
+{{end}}`))
+
+// The fields of an item, reply or user as displayed on the page. Text is
+// sanitized HTML, so it is not escaped any further.
+type htmlItem struct {
+	Rank     int
+	Title    string
+	Url      string
+	Domain   string
+	Points   string
+	By       string
+	ByUrl    string
+	Time     string
+	PostUrl  string
+	Comments string
+	Text     template.HTML
+	Indent   int
+
+	// The title and url of the story that a comment is on, if shown.
+	Story    string
+	StoryUrl string
+}
+
+// Formats items as a self-contained HTML page styled after the HN front page.
+//
+// The page is started by whatever is written first, so that a user's profile
+// and their submissions end up on the same page, and ended along with a
+// collection or thread, or along with a profile that has no submissions to
+// follow it.
+type htmlFormatter struct {
+	opts Options
+
+	// Whether the start of the page has been written.
+	started bool
+
+	// Rank of the last item written in the current collection.
+	rank int
+}
+
+func NewHtmlFormatter(opts Options) Formatter {
+	return &htmlFormatter{opts: opts}
+}
+
+func (f *htmlFormatter) Begin(w io.Writer) {
+	f.start(w)
+	f.rank = 0
+	fmt.Fprint(w, "<table class=\"items\">\n")
+}
+
+func (f *htmlFormatter) WriteItem(item *api.Item, w io.Writer) {
+	f.writeItem(f.pageItem(item), w)
+}
+
+// Search results are written as items, with comments linking to the story they
+// are on as on HN.
+func (f *htmlFormatter) WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer) {
+	page := f.pageItem(item)
+	if item.Type == api.Comment && !searchRepliesKnown(item) {
+		page.Comments = ""
+	}
+	if story := searchStory(item, result); story != nil {
+		page.Story = storyTitle(story)
+		page.StoryUrl = itemPostUrl(story)
+	}
+	f.writeItem(page, w)
+}
+
+func (f *htmlFormatter) writeItem(page htmlItem, w io.Writer) {
+	f.rank++
+	page.Rank = f.rank
+	f.execute("item", page, w)
+}
+
+func (f *htmlFormatter) End(w io.Writer) {
+	fmt.Fprint(w, "</table>\n")
+	f.end(w)
+}
+
+func (f *htmlFormatter) WriteUser(user *api.User, w io.Writer) {
+	f.start(w)
+	f.execute("user", htmlItem{
+		By:       user.Id,
+		ByUrl:    userBaseUrl + user.Id,
+		Points:   fmt.Sprintf("%d karma", user.Karma),
+		Time:     f.opts.formatTime(time.Unix(user.Created, 0)),
+		Comments: countOf(len(user.Submitted), "submission", "submissions"),
+		Text:     template.HTML(SanitizeHtml(derefStrOr(user.About, ""))),
+	}, w)
+}
+
+// Writes the user's profile and their submissions, if any, as a whole page.
+func (f *htmlFormatter) WriteUserSubmissions(user *api.User, submissions []api.Item, w io.Writer) {
+	f.WriteUser(user, w)
+	if len(submissions) > 0 {
+		WriteItems(f, submissions, w)
+	} else {
+		f.end(w)
+	}
+}
+
+func (f *htmlFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	f.start(w)
+	fmt.Fprint(w, "<table class=\"items\">\n")
+	f.execute("item", f.pageItem(&thread.Item), w)
+	fmt.Fprint(w, "</table>\n")
+	if thread.Text != nil && thread.Type != api.Comment {
+		// E.g. the text of Ask HN stories.
+		fmt.Fprintf(w, "<div class=\"commtext\">%s</div>\n", SanitizeHtml(*thread.Text))
+	}
+	for i := range thread.Replies {
+		f.writeReply(&thread.Replies[i], 0, w)
+	}
+	f.end(w)
+}
+
+func (f *htmlFormatter) writeReply(reply *api.Thread, depth int, w io.Writer) {
+	page := f.pageItem(&reply.Item)
+	page.Indent = depth * htmlCommentIndent
+	f.execute("reply", page, w)
+	for i := range reply.Replies {
+		f.writeReply(&reply.Replies[i], depth+1, w)
+	}
+}
+
+func (f *htmlFormatter) start(w io.Writer) {
+	if !f.started {
+		f.execute("head", nil, w)
+		f.started = true
+	}
+}
+
+func (f *htmlFormatter) end(w io.Writer) {
+	fmt.Fprint(w, "</body>\n</html>\n")
+	f.started = false
+}
+
+func (f *htmlFormatter) execute(name string, data any, w io.Writer) {
+	if err := htmlTemplates.ExecuteTemplate(w, name, data); err != nil {
+		panic(fmt.Sprintf("error formatting as html: %s", err.Error()))
+	}
+}
+
+// Returns how the item is displayed on the page. Items without titles, i.e.
+// comments and poll options, are displayed with their text instead.
+func (f *htmlFormatter) pageItem(item *api.Item) htmlItem {
+	page := htmlItem{
+		Url:     itemUrl(item),
+		By:      itemBy(item),
+		Time:    f.opts.itemTime(item),
+		PostUrl: itemPostUrl(item),
+	}
+	if item.By != nil {
+		page.ByUrl = userBaseUrl + *item.By
+	}
+	if item.Url != nil {
+		page.Domain = urlDomain(*item.Url)
+	}
+
+	switch item.Type {
+	case api.Job:
+		// As on HN, jobs are listed without points or authors.
+		page.Title = itemContent(item, item.Title, htmlToLine)
+		page.By = ""
+	case api.Story, api.Poll:
+		page.Title = itemContent(item, item.Title, htmlToLine)
+		page.Points = itemPoints(item)
+		page.Comments = itemComments(item)
+	case api.PollOpt:
+		page.Text = template.HTML(itemContent(item, item.Text, SanitizeHtml))
+		page.Points = itemPoints(item)
+	default:
+		page.Text = template.HTML(itemContent(item, item.Text, SanitizeHtml))
+		page.Comments = itemReplies(item)
+	}
+	return page
+}
+
+// Returns the domain of the url without any "www." prefix, as shown next to
+// titles on HN, or nothing if it cannot be parsed.
+func urlDomain(rawUrl string) string {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(parsed.Hostname(), "www.")
+}
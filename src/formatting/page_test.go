@@ -0,0 +1,73 @@
+package formatting
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHtmlOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteItems(NewHtmlFormatter(fakeOptions), feedItems(), &output)
+
+	assertGolden(t, "items.html", output.Bytes())
+}
+
+func TestHtmlThreadOutput(t *testing.T) {
+	var output bytes.Buffer
+	NewHtmlFormatter(fakeOptions).WriteThread(&thread, &output)
+
+	assertGolden(t, "thread.html", output.Bytes())
+}
+
+func TestHtmlOutputEscapesTitlesAndSanitizesText(t *testing.T) {
+	story, comment := story, comment
+	story.Title = ptr(`&lt;script&gt;alert(&quot;title&quot;)&lt;/script&gt;`)
+	story.Url = ptr(`javascript:alert("url")`)
+	comment.Text = ptr(`<script>alert("text")</script><a href="javascript:alert(1)" onclick="alert(2)">link</a>`)
+
+	var output bytes.Buffer
+	WriteItems(NewHtmlFormatter(fakeOptions), []api.Item{story, comment}, &output)
+
+	assert.NotContains(t, output.String(), "<script>alert")
+	assert.NotContains(t, output.String(), "javascript:")
+	assert.NotContains(t, output.String(), "onclick")
+	assert.Contains(t, output.String(), `&lt;script&gt;alert(&#34;title&#34;)&lt;/script&gt;`)
+	assert.Contains(t, output.String(), `&lt;script&gt;alert(&#34;text&#34;)&lt;/script&gt;<a>link</a>`)
+}
+
+func TestHtmlUserAndSubmissionsShareAPage(t *testing.T) {
+	user := user
+	user.About = ptr("Hi <i>there</i>")
+
+	var output bytes.Buffer
+	formatter := NewHtmlFormatter(fakeOptions)
+	formatter.WriteUser(&user, &output)
+	WriteItems(formatter, []api.Item{story}, &output)
+
+	assert.Equal(t, 1, strings.Count(output.String(), "<!DOCTYPE html>"))
+	assert.Equal(t, 1, strings.Count(output.String(), "</html>"))
+	assert.Contains(t, output.String(), `<div class="commtext">Hi <i>there</i></div>`)
+	assert.Contains(t, output.String(), "1234 karma | joined 2 years ago | 3 submissions")
+	assert.Contains(t, output.String(), `<td class="rank">1.</td>`)
+}
+
+func TestHtmlUserPageIsEndedWithoutSubmissions(t *testing.T) {
+	for _, submissions := range [][]api.Item{nil, {}, {story}} {
+		var output bytes.Buffer
+		WriteUser(NewHtmlFormatter(fakeOptions), &user, submissions, &output)
+
+		assert.Equal(t, 1, strings.Count(output.String(), "<!DOCTYPE html>"))
+		assert.True(t, strings.HasSuffix(output.String(), "</body>\n</html>\n"))
+	}
+}
+
+func TestUrlDomain(t *testing.T) {
+	assert.Equal(t, "example.com", urlDomain("https://www.example.com/a/b?c=d"))
+	assert.Equal(t, "blog.example.co.uk", urlDomain("http://blog.example.co.uk"))
+	assert.Equal(t, "", urlDomain("www.story.url"))
+	assert.Equal(t, "", urlDomain("://bad"))
+}
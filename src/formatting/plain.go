@@ -0,0 +1,152 @@
+package formatting
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+func init() {
+	RegisterStyle(Plain, NewPlainFormatter)
+}
+
+// Formats items as human-readable text, one or two lines each.
+type plainFormatter struct {
+	opts Options
+}
+
+func NewPlainFormatter(opts Options) Formatter {
+	return &plainFormatter{opts: opts}
+}
+
+func (f *plainFormatter) Begin(w io.Writer) {}
+
+func (f *plainFormatter) End(w io.Writer) {}
+
+func (f *plainFormatter) WriteItem(item *api.Item, w io.Writer) {
+	switch item.Type {
+	case api.Job:
+		f.writeJob(item, w)
+	case api.Story:
+		f.writeStory(item, w)
+	case api.Poll:
+		f.writePoll(item, w)
+	case api.PollOpt:
+		f.writePollOpt(item, w)
+	case api.Comment:
+		f.writeComment(item, true, w)
+	default:
+		f.writeUnknown(item, w)
+	}
+}
+
+// Writes an item of a type that HN does not document, which should not happen,
+// as a link to its page rather than failing.
+func (f *plainFormatter) writeUnknown(item *api.Item, w io.Writer) {
+	time := f.opts.itemTime(item)
+	fmt.Fprintf(w, "%s\n└─── by %s %s\n", f.opts.fit(unknownTypePlaceholder+" "+itemPostUrl(item)), itemBy(item), time)
+}
+
+func (f *plainFormatter) writeJob(job *api.Item, w io.Writer) {
+	time := f.opts.itemTime(job)
+	fmt.Fprintf(w, "%s\n└─── %s %s\n", f.opts.fit(itemStatusPrefix(job)+"HIRING: "+itemPostUrl(job)), itemPoints(job), time)
+}
+
+func (f *plainFormatter) writeStory(story *api.Item, w io.Writer) {
+	time := f.opts.itemTime(story)
+	fmt.Fprintf(w, "%s\n└─── %s by %s %s | %s\n", f.opts.fit(itemStatusPrefix(story)+itemUrl(story)), itemPoints(story), itemBy(story), time, itemComments(story))
+}
+
+func (f *plainFormatter) writePoll(poll *api.Item, w io.Writer) {
+	time := f.opts.itemTime(poll)
+	fmt.Fprintf(w, "%s\n└─── %s by %s %s | %s\n", f.opts.fit(itemStatusPrefix(poll)+itemPostUrl(poll)), itemPoints(poll), itemBy(poll), time, itemComments(poll))
+}
+
+func (f *plainFormatter) writePollOpt(pollopt *api.Item, w io.Writer) {
+	text := f.opts.preview(itemContent(pollopt, pollopt.Text, htmlToLine))
+	time := f.opts.itemTime(pollopt)
+	fmt.Fprintf(w, "%s\n└─── %s by %s %s\n", text, itemPoints(pollopt), itemBy(pollopt), time)
+}
+
+// Writes a comment, along with how many replies it has if withReplies is set.
+func (f *plainFormatter) writeComment(comment *api.Item, withReplies bool, w io.Writer) {
+	text := f.opts.preview(itemContent(comment, comment.Text, htmlToLine))
+	time := f.opts.itemTime(comment)
+	fmt.Fprintf(w, "%s\n└─── by %s %s%s\n", text, itemBy(comment), time, plainReplies(comment, withReplies))
+}
+
+// Like `writeComment`, but along with links to the comment and the story it is
+// on, in that order.
+func (f *plainFormatter) writeCommentOnStory(comment *api.Item, story *api.Item, withReplies bool, w io.Writer) {
+	text := f.opts.preview(itemContent(comment, comment.Text, htmlToLine))
+	links := f.opts.fit(fmt.Sprintf("│    %s | %s", itemPostUrl(comment), itemPostUrl(story)))
+	time := f.opts.itemTime(comment)
+	fmt.Fprintf(w, "%s\n%s\n└─── comment by %s on %s %s%s\n", text, links, itemBy(comment), storyTitle(story), time, plainReplies(comment, withReplies))
+}
+
+func plainReplies(comment *api.Item, withReplies bool) string {
+	if !withReplies {
+		return ""
+	}
+	return " | " + itemReplies(comment)
+}
+
+func (f *plainFormatter) WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer) {
+	marked := markSearchMatches(item, result)
+	var snippetLine string
+	if snippet := searchSnippet(result); len(snippet) > 0 {
+		snippetLine = f.opts.fit("│    " + htmlToLine(snippet))
+	}
+	start, end := "", ""
+	if f.opts.Ansi {
+		start, end = ansiBold, ansiBoldReset
+	}
+	writeItem := func(w io.Writer) { f.WriteItem(&marked, w) }
+	if story := searchStory(item, result); story != nil {
+		writeItem = func(w io.Writer) { f.writeCommentOnStory(&marked, story, searchRepliesKnown(item), w) }
+	} else if item.Type == api.Comment {
+		writeItem = func(w io.Writer) { f.writeComment(&marked, searchRepliesKnown(item), w) }
+	}
+	writeSearchResult(writeItem, snippetLine, start, end, w)
+}
+
+func (f *plainFormatter) WriteUser(user *api.User, w io.Writer) {
+	about := HtmlToText(derefStrOr(user.About, ""))
+	time := f.opts.formatTime(time.Unix(user.Created, 0))
+	submissions := countOf(len(user.Submitted), "submission", "submissions")
+
+	fmt.Fprintf(w, "%s\n", user.Id)
+	for _, line := range nonEmptyLines(f.opts.wrap(about, StringWidth("│    "))) {
+		fmt.Fprintf(w, "│    %s\n", line)
+	}
+	fmt.Fprintf(w, "└─── %d karma | joined %s | %s\n", user.Karma, time, submissions)
+}
+
+func (f *plainFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	f.WriteItem(&thread.Item, w)
+	for i := range thread.Replies {
+		f.writeReply(&thread.Replies[i], 1, w)
+	}
+}
+
+func (f *plainFormatter) writeReply(reply *api.Thread, depth int, w io.Writer) {
+	text := itemContent(&reply.Item, reply.Text, HtmlToText)
+	time := f.opts.itemTime(&reply.Item)
+	indent := strings.Repeat("    ", depth)
+
+	for _, line := range f.opts.wrap(text, len(indent)) {
+		if len(line) == 0 {
+			fmt.Fprintln(w)
+		} else {
+			fmt.Fprintf(w, "%s%s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(w, "%s└─── by %s %s\n", indent, itemBy(&reply.Item), time)
+
+	for i := range reply.Replies {
+		f.writeReply(&reply.Replies[i], depth+1, w)
+	}
+}
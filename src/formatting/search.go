@@ -0,0 +1,169 @@
+package formatting
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+// Private use characters that stand in for the <em> tags that Algolia marks
+// the matched terms of search results with, which would otherwise be dropped
+// along with the rest of the HN HTML before the matches are rendered.
+const (
+	matchStart = "\ue000"
+	matchEnd   = "\ue001"
+)
+
+const (
+	ansiBold      = "\x1b[1m"
+	ansiBoldReset = "\x1b[22m"
+)
+
+var matchMarker = strings.NewReplacer("<em>", matchStart, "</em>", matchEnd)
+
+// The attributes of search results that snippets are shown of, in order of
+// preference.
+var snippetAttributes = []string{"story_text", "comment_text", "title", "url"}
+
+// A `Formatter` that can show why items matched a search, and what story the
+// comments among them are on.
+type SearchFormatter interface {
+	Formatter
+
+	// Writes the item like `WriteItem`, but with the terms that matched the
+	// search emphasized, along with a snippet of where they matched, if the
+	// style has room for them. Comments are written along with their story.
+	WriteSearchResult(item *api.Item, result *api.SearchResult, w io.Writer)
+}
+
+// Writes items found by search with why they matched, if the base formatter is
+// a `SearchFormatter`, and others as usual. Whatever else the base formatter
+// implements, e.g. streaming, carries over.
+type searchResultsFormatter struct {
+	SearchFormatter
+	results map[api.ItemId]*api.SearchResult
+}
+
+// Returns a formatter that writes the items in the search results with why
+// they matched, if the base formatter can, or the base formatter otherwise.
+func NewSearchResultsFormatter(base Formatter, results []api.SearchResult) Formatter {
+	searchFormatter, ok := base.(SearchFormatter)
+	if !ok {
+		return base
+	}
+	f := &searchResultsFormatter{searchFormatter, map[api.ItemId]*api.SearchResult{}}
+	for i := range results {
+		f.results[results[i].Id] = &results[i]
+	}
+	return f
+}
+
+func (f *searchResultsFormatter) WriteItem(item *api.Item, w io.Writer) {
+	if result, ok := f.results[item.Id]; ok {
+		f.WriteSearchResult(item, result, w)
+	} else {
+		f.SearchFormatter.WriteItem(item, w)
+	}
+}
+
+func (f *searchResultsFormatter) Streaming() bool {
+	return IsStreaming(f.SearchFormatter)
+}
+
+func (f *searchResultsFormatter) Err() error {
+	return FormatterErr(f.SearchFormatter)
+}
+
+func (f *searchResultsFormatter) WriteUserSubmissions(user *api.User, submissions []api.Item, w io.Writer) {
+	WriteUser(f.SearchFormatter, user, submissions, w)
+}
+
+// Returns the story that the search result is on, as far as search knows it,
+// if the item is a comment, or nil otherwise. Only the story's id is sure to
+// be known.
+func searchStory(item *api.Item, result *api.SearchResult) *api.Item {
+	if item.Type != api.Comment || result.StoryId == nil {
+		return nil
+	}
+	return &api.Item{Id: *result.StoryId, Title: result.StoryTitle, Url: result.StoryUrl}
+}
+
+// Reports whether the number of replies to a comment found by search is known.
+// Search does not know the replies of comments, so they are only known once
+// refetched from HN. Since HN leaves out the replies of comments without any,
+// those are taken to be unknown too.
+func searchRepliesKnown(item *api.Item) bool {
+	return item.Kids != nil
+}
+
+// Returns the title of the story that a comment is on, falling back to the
+// story's post url if search does not know it.
+func storyTitle(story *api.Item) string {
+	if story.Title == nil {
+		return itemPostUrl(story)
+	}
+	return htmlToLine(*story.Title)
+}
+
+// Returns a copy of the item with the terms that matched the search marked in
+// its title, url and text.
+func markSearchMatches(item *api.Item, result *api.SearchResult) api.Item {
+	marked := *item
+	mark := func(field **string, attributes ...string) {
+		for _, attribute := range attributes {
+			if highlight, ok := result.Highlights[attribute]; ok && *field != nil {
+				highlight = matchMarker.Replace(highlight)
+				*field = &highlight
+				return
+			}
+		}
+	}
+	mark(&marked.Title, "title")
+	mark(&marked.Url, "url")
+	mark(&marked.Text, "story_text", "comment_text")
+	if marked.Url != item.Url {
+		// Unlike titles and text, urls are shown as is rather than as HTML.
+		url := html.UnescapeString(*marked.Url)
+		marked.Url = &url
+	}
+	return marked
+}
+
+// Returns the first snippet of the search result that has matched terms in
+// it, marked, or nothing if none do.
+func searchSnippet(result *api.SearchResult) string {
+	for _, attribute := range snippetAttributes {
+		if snippet := result.Snippets[attribute]; strings.Contains(snippet, "<em>") {
+			return matchMarker.Replace(snippet)
+		}
+	}
+	return ""
+}
+
+// Writes an item as written by writeItem, with the given snippet line inserted
+// after its first line, i.e. that with its url, title or text. Marked matches
+// are rendered between start and end, including those cut short by
+// truncation.
+func writeSearchResult(writeItem func(w io.Writer), snippetLine string, start string, end string, w io.Writer) {
+	var buf bytes.Buffer
+	writeItem(&buf)
+	lines := strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(snippetLine) > 0 {
+		lines = append(lines[:1], append([]string{snippetLine + "\n"}, lines[1:]...)...)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(w, renderMatches(strings.TrimSuffix(line, "\n"), start, end))
+	}
+}
+
+// Replaces the marks around matched terms in a line, closing any left open.
+func renderMatches(line string, start string, end string) string {
+	if strings.Count(line, matchStart) > strings.Count(line, matchEnd) {
+		line += matchEnd
+	}
+	return strings.NewReplacer(matchStart, start, matchEnd, end).Replace(line)
+}
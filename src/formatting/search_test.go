@@ -0,0 +1,223 @@
+package formatting
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	storyResult = api.SearchResult{
+		Id: story.Id,
+		Highlights: map[string]string{
+			"title": "<em>Story</em> title",
+			"url":   "www.<em>story</em>.url",
+		},
+		Snippets: map[string]string{
+			"title": "<em>Story</em> title",
+			"url":   "www.<em>story</em>.url",
+		},
+	}
+
+	commentResult = api.SearchResult{
+		Id: comment.Id,
+		Highlights: map[string]string{
+			"comment_text": "Comment <em>text</em>",
+		},
+		Snippets: map[string]string{
+			"comment_text": "… Comment <em>text</em>",
+		},
+	}
+
+	commentOnStoryResult = api.SearchResult{
+		Id:         comment.Id,
+		StoryId:    &story.Id,
+		StoryTitle: story.Title,
+		StoryUrl:   story.Url,
+		Highlights: commentResult.Highlights,
+		Snippets:   commentResult.Snippets,
+	}
+)
+
+func TestPlainSearchResultOutput(t *testing.T) {
+	var output, ansiOutput bytes.Buffer
+
+	NewSearchResultsFormatter(NewPlainFormatter(fakeOptions), []api.SearchResult{storyResult, commentResult}).WriteItem(&story, &output)
+	ansiOptions := Options{Clock: &fakeClock, Ansi: true}
+	formatter := NewSearchResultsFormatter(NewPlainFormatter(ansiOptions), []api.SearchResult{storyResult, commentResult})
+	WriteItems(formatter, []api.Item{story, comment, job}, &ansiOutput)
+
+	expectedOutput := "www.story.url\n" +
+		"│    Story title\n" +
+		"└─── 10 pts by storyuser 12 days ago | 20 comments\n"
+	expectedAnsiOutput := "www.\x1b[1mstory\x1b[22m.url\n" +
+		"│    \x1b[1mStory\x1b[22m title\n" +
+		"└─── 10 pts by storyuser 12 days ago | 20 comments\n" +
+		"Comment \x1b[1mtext\x1b[22m\n" +
+		"│    … Comment \x1b[1mtext\x1b[22m\n" +
+		"└─── by commentuser a day ago | 4 replies\n" +
+		"HIRING: https://news.ycombinator.com/item?id=1\n" +
+		"└─── 1 pt 6 hours ago\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+	assert.Equal(t, expectedAnsiOutput, ansiOutput.String())
+}
+
+func TestMarkdownSearchResultOutput(t *testing.T) {
+	var output bytes.Buffer
+
+	formatter := NewSearchResultsFormatter(NewMarkdownFormatter(fakeOptions), []api.SearchResult{storyResult, commentResult})
+	WriteItems(formatter, []api.Item{story, comment}, &output)
+
+	expectedOutput := "* **[*Story* title](www.story.url)**\n" +
+		"* > **Story** title\n" +
+		"* └─── 10 pts by [storyuser](https://news.ycombinator.com/user?id=storyuser) 12 days ago | [20 comments](https://news.ycombinator.com/item?id=2)\n" +
+		"* *[Comment **text**](https://news.ycombinator.com/item?id=5)*\n" +
+		"* > … Comment **text**\n" +
+		"* └─── by [commentuser](https://news.ycombinator.com/user?id=commentuser) a day ago | [4 replies](https://news.ycombinator.com/item?id=5)\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestSearchResultWithoutMatchesHasNoSnippet(t *testing.T) {
+	var output bytes.Buffer
+
+	formatter := NewSearchResultsFormatter(NewPlainFormatter(fakeOptions), []api.SearchResult{{Id: story.Id}})
+	formatter.WriteItem(&story, &output)
+
+	assert.Equal(t, "www.story.url\n└─── 10 pts by storyuser 12 days ago | 20 comments\n", output.String())
+}
+
+func TestTruncatedSearchMatchesAreClosed(t *testing.T) {
+	var output bytes.Buffer
+
+	long := story
+	long.Url = ptr("https://example.com/a/very/long/path/to/a/story")
+	result := api.SearchResult{
+		Id:         long.Id,
+		Highlights: map[string]string{"url": "https://example.com/a/very/<em>long/path/to/a/story</em>"},
+	}
+	opts := Options{Clock: &fakeClock, Width: 35, Ansi: true}
+	NewSearchResultsFormatter(NewPlainFormatter(opts), []api.SearchResult{result}).WriteItem(&long, &output)
+
+	assert.Equal(t, "https://example.com/a/very/\x1b[1mlong...\x1b[22m", strings.Split(output.String(), "\n")[0])
+}
+
+func TestSearchResultsFormatterIsBaseFormatterIfItCannotShowMatches(t *testing.T) {
+	base, err := NewTemplateFormatter("{{.Id}}", fakeOptions, NewJsonFormatter(fakeOptions))
+	assert.Nil(t, err)
+
+	assert.Same(t, base, NewSearchResultsFormatter(base, []api.SearchResult{storyResult}))
+}
+
+func TestSearchResultsFormatterKeepsWhetherBaseFormatterStreams(t *testing.T) {
+	results := []api.SearchResult{storyResult}
+
+	assert.True(t, IsStreaming(NewSearchResultsFormatter(NewJsonlFormatter(fakeOptions), results)))
+	assert.False(t, IsStreaming(NewSearchResultsFormatter(NewJsonFormatter(fakeOptions), results)))
+	assert.Nil(t, FormatterErr(NewSearchResultsFormatter(NewJsonlFormatter(fakeOptions), results)))
+}
+
+func TestSearchResultsFormatterWritesUsersAsBaseFormatterDoes(t *testing.T) {
+	var output, expectedOutput bytes.Buffer
+
+	WriteUser(NewSearchResultsFormatter(NewJsonFormatter(fakeOptions), []api.SearchResult{storyResult}), &user, []api.Item{story}, &output)
+	WriteUser(NewJsonFormatter(fakeOptions), &user, []api.Item{story}, &expectedOutput)
+
+	assert.Equal(t, expectedOutput.String(), output.String())
+}
+
+func TestPlainCommentSearchResultOutput(t *testing.T) {
+	var output, unknownTitleOutput bytes.Buffer
+
+	NewSearchResultsFormatter(NewPlainFormatter(fakeOptions), []api.SearchResult{commentOnStoryResult}).WriteItem(&comment, &output)
+	unknownTitle := commentOnStoryResult
+	unknownTitle.StoryTitle = nil
+	NewSearchResultsFormatter(NewPlainFormatter(fakeOptions), []api.SearchResult{unknownTitle}).WriteItem(&comment, &unknownTitleOutput)
+
+	expectedOutput := "Comment text\n" +
+		"│    … Comment text\n" +
+		"│    https://news.ycombinator.com/item?id=5 | https://news.ycombinator.com/item?id=2\n" +
+		"└─── comment by commentuser on Story title a day ago | 4 replies\n"
+	expectedUnknownTitleOutput := "Comment text\n" +
+		"│    … Comment text\n" +
+		"│    https://news.ycombinator.com/item?id=5 | https://news.ycombinator.com/item?id=2\n" +
+		"└─── comment by commentuser on https://news.ycombinator.com/item?id=2 a day ago | 4 replies\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+	assert.Equal(t, expectedUnknownTitleOutput, unknownTitleOutput.String())
+}
+
+func TestMarkdownCommentSearchResultOutput(t *testing.T) {
+	var output bytes.Buffer
+
+	NewSearchResultsFormatter(NewMarkdownFormatter(fakeOptions), []api.SearchResult{commentOnStoryResult}).WriteItem(&comment, &output)
+
+	expectedOutput := "* *[Comment **text**](https://news.ycombinator.com/item?id=5)*\n" +
+		"* > … Comment **text**\n" +
+		"* └─── comment by [commentuser](https://news.ycombinator.com/user?id=commentuser) on [Story title](https://news.ycombinator.com/item?id=2) a day ago | [4 replies](https://news.ycombinator.com/item?id=5)\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestHtmlCommentSearchResultOutput(t *testing.T) {
+	var output bytes.Buffer
+
+	formatter := NewSearchResultsFormatter(NewHtmlFormatter(fakeOptions), []api.SearchResult{commentOnStoryResult})
+	WriteItems(formatter, []api.Item{comment}, &output)
+
+	assert.Contains(t, output.String(), `<a href="https://news.ycombinator.com/item?id=5">a day ago</a> | <a href="https://news.ycombinator.com/item?id=5">4 replies</a> | on: <a href="https://news.ycombinator.com/item?id=2">Story title</a>`)
+}
+
+func TestFeedCommentSearchResultOutput(t *testing.T) {
+	var rssOutput, atomOutput bytes.Buffer
+
+	rss := NewSearchResultsFormatter(NewRssFormatter(fakeOptions), []api.SearchResult{commentOnStoryResult})
+	WriteItems(rss, []api.Item{comment}, &rssOutput)
+	atom := NewSearchResultsFormatter(NewAtomFormatter(fakeOptions), []api.SearchResult{commentOnStoryResult})
+	WriteItems(atom, []api.Item{comment}, &atomOutput)
+
+	assert.Contains(t, rssOutput.String(), "<title>Comment by commentuser on Story title</title>")
+	assert.Contains(t, rssOutput.String(), "<link>https://news.ycombinator.com/item?id=5</link>")
+	assert.Contains(t, rssOutput.String(), "<description>Comment text&lt;p&gt;on: &lt;a href=&#34;https://news.ycombinator.com/item?id=2&#34;&gt;Story title&lt;/a&gt;&lt;/p&gt;</description>")
+	assert.Contains(t, atomOutput.String(), "<title>Comment by commentuser on Story title</title>")
+	assert.Contains(t, atomOutput.String(), `<link rel="related" type="text/html" href="https://news.ycombinator.com/item?id=2"></link>`)
+}
+
+func TestStructuredCommentSearchResultOutput(t *testing.T) {
+	var jsonlOutput, jsonlFieldsOutput, csvOutput bytes.Buffer
+
+	results := []api.SearchResult{storyResult, commentOnStoryResult}
+	WriteItems(NewSearchResultsFormatter(NewJsonlFormatter(fakeOptions), results), []api.Item{comment}, &jsonlOutput)
+	opts := Options{Clock: &fakeClock, Fields: []string{"id", "story_id", "story_title"}}
+	WriteItems(NewSearchResultsFormatter(NewJsonlFormatter(opts), results), []api.Item{story, comment}, &jsonlFieldsOutput)
+	WriteItems(NewSearchResultsFormatter(NewCsvFormatter(opts), results), []api.Item{story, comment}, &csvOutput)
+
+	assert.Contains(t, jsonlOutput.String(), `"descendants":null,"story_id":2,"story_title":"Story title","story_url":"www.story.url"}`)
+	assert.Equal(t, `{"id":2,"story_id":null,"story_title":null}`+"\n"+`{"id":5,"story_id":2,"story_title":"Story title"}`+"\n", jsonlFieldsOutput.String())
+	assert.Equal(t, "2,,\n5,2,Story title\n", csvOutput.String())
+}
+
+func TestCommentSearchResultFromSearchDataHasNoReplyCount(t *testing.T) {
+	var plainOutput, markdownOutput, htmlOutput bytes.Buffer
+
+	result := commentOnStoryResult
+	result.Author = comment.By
+	result.CreatedAt = comment.Time
+	result.CommentText = comment.Text
+	result.Tags = []string{"comment", "author_commentuser", "story_2"}
+	item := result.ToItem()
+	results := []api.SearchResult{result}
+
+	NewSearchResultsFormatter(NewPlainFormatter(fakeOptions), results).WriteItem(&item, &plainOutput)
+	NewSearchResultsFormatter(NewMarkdownFormatter(fakeOptions), results).WriteItem(&item, &markdownOutput)
+	WriteItems(NewSearchResultsFormatter(NewHtmlFormatter(fakeOptions), results), []api.Item{item}, &htmlOutput)
+
+	assert.True(t, strings.HasSuffix(plainOutput.String(), "└─── comment by commentuser on Story title a day ago\n"))
+	assert.True(t, strings.HasSuffix(markdownOutput.String(), "on [Story title](https://news.ycombinator.com/item?id=2) a day ago\n"))
+	assert.NotContains(t, htmlOutput.String(), "replies")
+	assert.Contains(t, htmlOutput.String(), `<a href="https://news.ycombinator.com/item?id=5">a day ago</a> | on: <a href="https://news.ycombinator.com/item?id=2">Story title</a>`)
+}
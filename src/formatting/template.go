@@ -0,0 +1,138 @@
+package formatting
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"text/template"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+// Formats each item by executing a user-supplied text/template against it,
+// followed by a newline. Templates see the `api.Item` fields as fetched, e.g.
+// `{{.Title}}`, along with the helpers in `templateFuncs`.
+//
+// Templates only apply to items, so user profiles are written by the base
+// formatter instead. Items the template fails on are skipped, with the first
+// failure kept for `Err`.
+type templateFormatter struct {
+	tmpl *template.Template
+	base Formatter
+	err  error
+}
+
+// Parses the template, making sure it can be executed against an item, and
+// returns a formatter for it that writes user profiles with the base
+// formatter.
+func NewTemplateFormatter(text string, opts Options, base Formatter) (Formatter, error) {
+	tmpl, err := template.New("format").Funcs(templateFuncs(opts)).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid format: %s\n", err.Error())
+	}
+	// Catch references to fields that do not exist up front rather than while
+	// writing. Every field is missing from an empty item, so the helpers must
+	// also cope with those.
+	if err := tmpl.Execute(io.Discard, &api.Item{}); err != nil {
+		return nil, fmt.Errorf("invalid format: %s\n", err.Error())
+	}
+	return &templateFormatter{tmpl: tmpl, base: base}, nil
+}
+
+func (f *templateFormatter) Begin(w io.Writer) {}
+
+func (f *templateFormatter) End(w io.Writer) {}
+
+func (f *templateFormatter) WriteItem(item *api.Item, w io.Writer) {
+	// Execute into a buffer so that a failure does not leave half an item.
+	var buf bytes.Buffer
+	if err := f.tmpl.Execute(&buf, item); err != nil {
+		if f.err == nil {
+			f.err = fmt.Errorf("error formatting item %d with template: %s\n", item.Id, err.Error())
+		}
+		return
+	}
+	buf.WriteString("\n")
+	w.Write(buf.Bytes())
+}
+
+func (f *templateFormatter) Err() error {
+	return f.err
+}
+
+func (f *templateFormatter) WriteUser(user *api.User, w io.Writer) {
+	f.base.WriteUser(user, w)
+}
+
+func (f *templateFormatter) WriteThread(thread *api.Thread, w io.Writer) {
+	WriteItems(f, flattenThread(thread, nil), w)
+}
+
+// Returns the helpers available to templates. Those taking strings or times
+// accept the item's pointer fields as is, treating nil as empty.
+func templateFuncs(opts Options) template.FuncMap {
+	return template.FuncMap{
+		// Dereferences a pointer field, or returns its zero value if nil.
+		"deref": func(v any) any {
+			value := reflect.ValueOf(v)
+			if value.Kind() != reflect.Pointer {
+				return v
+			}
+			if value.IsNil() {
+				return reflect.Zero(value.Type().Elem()).Interface()
+			}
+			return value.Elem().Interface()
+		},
+
+		// Returns the relative time of a unix timestamp, e.g. "2 hours ago".
+		"ago": func(timestamp *int64) string {
+			if timestamp == nil {
+				return opts.locale().Unknown
+			}
+			return GetLocalizedRelativeTime(opts.Clock, time.Unix(*timestamp, 0), opts.locale())
+		},
+
+		// Returns a unix timestamp in the time format of the options.
+		"time": func(timestamp *int64) string {
+			if timestamp == nil {
+				return opts.locale().Unknown
+			}
+			return opts.formatTime(time.Unix(*timestamp, 0))
+		},
+
+		// Returns the url of the item's HN page.
+		"postUrl": func(id api.ItemId) string {
+			return fmt.Sprintf("%s%d", itemBaseUrl, id)
+		},
+
+		// Returns the url of the user's HN profile.
+		"userUrl": func(v any) string {
+			return userBaseUrl + templateString(v)
+		},
+
+		// Converts HN HTML, e.g. a comment's text, to plain text.
+		"text": func(v any) string {
+			return HtmlToText(templateString(v))
+		},
+
+		// Shortens text to fit the given number of terminal columns.
+		"truncate": func(width int, v any) string {
+			return Truncate(templateString(v), width)
+		},
+	}
+}
+
+func templateString(v any) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case *string:
+		return derefStrOr(s, "")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
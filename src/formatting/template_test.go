@@ -0,0 +1,103 @@
+package formatting
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateOutput(t *testing.T) {
+	formatter, err := NewTemplateFormatter(`{{.Id}} {{deref .Score}} {{deref .Title}} ({{ago .Time}})`, fakeOptions, NewPlainFormatter(fakeOptions))
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	WriteItems(formatter, []api.Item{job, story, comment}, &output)
+
+	expectedOutput := "1 1 Job title (6 hours ago)\n" +
+		"2 10 Story title (12 days ago)\n" +
+		"5 0  (a day ago)\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestTemplateHelpers(t *testing.T) {
+	comment := comment
+	comment.Text = ptr(`I&#x27;d say <i>vim</i>, see <a href="https:&#x2F;&#x2F;vim.org">vim.org</a>`)
+
+	formatter, err := NewTemplateFormatter(`{{postUrl .Id}} {{userUrl .By}} {{truncate 12 (text .Text)}}`, fakeOptions, NewPlainFormatter(fakeOptions))
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	WriteItems(formatter, []api.Item{comment}, &output)
+
+	expectedOutput := "https://news.ycombinator.com/item?id=5 https://news.ycombinator.com/user?id=commentuser I'd say v...\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestTemplateHelpersHandleMissingFields(t *testing.T) {
+	formatter, err := NewTemplateFormatter(`[{{deref .By}}|{{deref .Score}}|{{ago .Time}}|{{text .Text}}|{{truncate 5 .Url}}|{{userUrl .By}}]`, fakeOptions, NewPlainFormatter(fakeOptions))
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	WriteItems(formatter, []api.Item{{Id: 1, Type: api.Story}}, &output)
+
+	assert.Equal(t, "[|0|at an unknown time|||https://news.ycombinator.com/user?id=]\n", output.String())
+}
+
+func TestTemplateTimeHelpers(t *testing.T) {
+	opts := Options{Clock: &fakeClock, Time: UnixTime, Locale: locales["fr"]}
+	formatter, err := NewTemplateFormatter(`{{ago .Time}} ({{time .Time}})`, opts, NewPlainFormatter(opts))
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	WriteItems(formatter, []api.Item{story, {Id: 1, Type: api.Story}}, &output)
+
+	assert.Equal(t, "il y a 12 jours (8963200)\nà une date inconnue (à une date inconnue)\n", output.String())
+}
+
+func TestTemplateThreadOutput(t *testing.T) {
+	formatter, err := NewTemplateFormatter(`{{.Id}}:{{deref .Parent}}`, fakeOptions, NewPlainFormatter(fakeOptions))
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	formatter.WriteThread(&thread, &output)
+
+	assert.Equal(t, "10:0\n11:10\n12:11\n13:10\n", output.String())
+}
+
+func TestTemplateUserOutputUsesBaseFormatter(t *testing.T) {
+	formatter, err := NewTemplateFormatter(`{{.Id}}`, fakeOptions, NewPlainFormatter(fakeOptions))
+	assert.Nil(t, err)
+
+	var output, expectedOutput bytes.Buffer
+	formatter.WriteUser(&user, &output)
+	WriteUserPlain(&user, fakeOptions, &expectedOutput)
+
+	assert.Equal(t, expectedOutput.String(), output.String())
+}
+
+func TestInvalidTemplates(t *testing.T) {
+	for _, format := range []string{
+		`{{.Title`,
+		`{{.NoSuchField}}`,
+		`{{noSuchHelper .Title}}`,
+	} {
+		_, err := NewTemplateFormatter(format, fakeOptions, NewPlainFormatter(fakeOptions))
+		assert.ErrorContains(t, err, "invalid format: ", format)
+	}
+}
+
+func TestTemplateExecutionErrorsAreKeptRatherThanWritten(t *testing.T) {
+	formatter, err := NewTemplateFormatter(`{{.Id}}{{if .Kids}} {{index .Kids 4}}{{end}}`, fakeOptions, NewPlainFormatter(fakeOptions))
+	assert.Nil(t, err)
+
+	var output bytes.Buffer
+	WriteItems(formatter, []api.Item{job, story, comment}, &output)
+
+	assert.Equal(t, "1\n2\n", output.String())
+	assert.ErrorContains(t, FormatterErr(formatter), "error formatting item 5 with template")
+	assert.Nil(t, FormatterErr(NewPlainFormatter(fakeOptions)))
+}
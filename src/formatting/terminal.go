@@ -0,0 +1,19 @@
+package formatting
+
+import "os"
+
+// Returns the width of the terminal the file refers to, in columns, or zero if
+// it does not refer to a terminal.
+func TerminalWidth(f *os.File) int {
+	width, ok := terminalWidth(f)
+	if !ok {
+		return 0
+	}
+	return width
+}
+
+// Reports whether the file refers to a terminal.
+func IsTerminal(f *os.File) bool {
+	_, ok := terminalWidth(f)
+	return ok
+}
@@ -0,0 +1,9 @@
+//go:build !(darwin || freebsd || linux || netbsd || openbsd)
+
+package formatting
+
+import "os"
+
+func terminalWidth(f *os.File) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,20 @@
+//go:build darwin || freebsd || linux || netbsd || openbsd
+
+package formatting
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func terminalWidth(f *os.File) (int, bool) {
+	var size struct {
+		rows, cols, xpixels, ypixels uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 || size.cols == 0 {
+		return 0, false
+	}
+	return int(size.cols), true
+}
@@ -0,0 +1,31 @@
+package formatting
+
+import (
+	"io"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+func WriteThreadPlain(thread *api.Thread, opts Options, w io.Writer) {
+	NewPlainFormatter(opts).WriteThread(thread, w)
+}
+
+func WriteThreadMarkdown(thread *api.Thread, opts Options, w io.Writer) {
+	NewMarkdownFormatter(opts).WriteThread(thread, w)
+}
+
+func WriteThreadJson(thread *api.Thread, w io.Writer) {
+	NewJsonFormatter(Options{}).WriteThread(thread, w)
+}
+
+func WriteThreadCsv(thread *api.Thread, w io.Writer) {
+	NewCsvFormatter(Options{}).WriteThread(thread, w)
+}
+
+func flattenThread(thread *api.Thread, items []api.Item) []api.Item {
+	items = append(items, thread.Item)
+	for i := range thread.Replies {
+		items = flattenThread(&thread.Replies[i], items)
+	}
+	return items
+}
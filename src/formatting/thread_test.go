@@ -0,0 +1,229 @@
+package formatting
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+var thread = api.Thread{
+	Item: api.Item{
+		Id:          10,
+		Type:        api.Story,
+		Score:       intptr(10),
+		By:          ptr("storyuser"),
+		Time:        ptr(now.Add(-3 * time.Hour).Unix()), // 3 hours ago
+		Descendants: intptr(3),
+		Title:       ptr("Story title"),
+		Url:         ptr("www.story.url"),
+		Kids:        []api.ItemId{11, 13},
+	},
+	Replies: []api.Thread{
+		{
+			Item: api.Item{
+				Id:     11,
+				Type:   api.Comment,
+				By:     ptr("firstuser"),
+				Time:   ptr(now.Add(-2 * time.Hour).Unix()), // 2 hours ago
+				Text:   ptr("First comment"),
+				Parent: ptr(api.ItemId(10)),
+				Kids:   []api.ItemId{12},
+			},
+			Replies: []api.Thread{
+				{
+					Item: api.Item{
+						Id:     12,
+						Type:   api.Comment,
+						By:     ptr("replyuser"),
+						Time:   ptr(now.Add(-1 * time.Hour).Unix()), // an hour ago
+						Text:   ptr("First reply"),
+						Parent: ptr(api.ItemId(11)),
+					},
+				},
+			},
+		},
+		{
+			Item: api.Item{
+				Id:      13,
+				Type:    api.Comment,
+				Deleted: ptr(true),
+				Time:    ptr(now.Add(-30 * time.Minute).Unix()), // 30 minutes ago
+				Parent:  ptr(api.ItemId(10)),
+			},
+		},
+	},
+}
+
+func TestThreadPlainOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteThreadPlain(&thread, fakeOptions, &output)
+
+	expectedOutput := `www.story.url
+└─── 10 pts by storyuser 3 hours ago | 3 comments
+    First comment
+    └─── by firstuser 2 hours ago
+        First reply
+        └─── by replyuser an hour ago
+    [deleted]
+    └─── by [deleted] 30 min ago
+`
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestThreadMarkdownOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteThreadMarkdown(&thread, fakeOptions, &output)
+
+	expectedOutput := `* **[Story title](www.story.url)**
+* └─── 10 pts by [storyuser](https://news.ycombinator.com/user?id=storyuser) 3 hours ago | [3 comments](https://news.ycombinator.com/item?id=10)
+  * First comment
+  * └─── by [firstuser](https://news.ycombinator.com/user?id=firstuser) [2 hours ago](https://news.ycombinator.com/item?id=11)
+    * First reply
+    * └─── by [replyuser](https://news.ycombinator.com/user?id=replyuser) [an hour ago](https://news.ycombinator.com/item?id=12)
+  * [deleted]
+  * └─── by [deleted] [30 min ago](https://news.ycombinator.com/item?id=13)
+`
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestThreadJsonOutput(t *testing.T) {
+	thread := api.Thread{
+		Item: api.Item{Id: 1, Type: api.Story, Kids: []api.ItemId{2}},
+		Replies: []api.Thread{
+			{Item: api.Item{Id: 2, Type: api.Comment, Parent: ptr(api.ItemId(1))}},
+		},
+	}
+
+	var output bytes.Buffer
+	WriteThreadJson(&thread, &output)
+
+	expectedOutput := `{
+	"id": 1,
+	"deleted": null,
+	"type": "story",
+	"by": null,
+	"time": null,
+	"text": null,
+	"dead": null,
+	"parent": null,
+	"poll": null,
+	"kids": [
+		2
+	],
+	"url": null,
+	"score": null,
+	"title": null,
+	"parts": null,
+	"descendants": null,
+	"replies": [
+		{
+			"id": 2,
+			"deleted": null,
+			"type": "comment",
+			"by": null,
+			"time": null,
+			"text": null,
+			"dead": null,
+			"parent": 1,
+			"poll": null,
+			"kids": null,
+			"url": null,
+			"score": null,
+			"title": null,
+			"parts": null,
+			"descendants": null,
+			"replies": null
+		}
+	]
+}
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestThreadCsvOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteThreadCsv(&thread, &output)
+
+	expectedOutput := `10,,story,storyuser,9989200,,,0,0,"11,13",www.story.url,10,Story title,,3
+11,,comment,firstuser,9992800,First comment,,10,0,12,,0,,,0
+12,,comment,replyuser,9996400,First reply,,11,0,,,0,,,0
+13,true,comment,,9998200,,,10,0,,,0,,,0
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestThreadOutputConvertsHtml(t *testing.T) {
+	thread := api.Thread{
+		Item: story,
+		Replies: []api.Thread{
+			{
+				Item: api.Item{
+					Id:   11,
+					Type: api.Comment,
+					By:   ptr("commentuser"),
+					Time: ptr(now.Add(-2 * time.Hour).Unix()), // 2 hours ago
+					Text: ptr(`First <i>paragraph</i>.<p>See <a href="https:&#x2F;&#x2F;example.com">this</a>`),
+				},
+			},
+		},
+	}
+
+	var plainOutput, markdownOutput bytes.Buffer
+	WriteThreadPlain(&thread, fakeOptions, &plainOutput)
+	WriteThreadMarkdown(&thread, fakeOptions, &markdownOutput)
+
+	expectedPlainOutput := `www.story.url
+└─── 10 pts by storyuser 12 days ago | 20 comments
+    First paragraph.
+
+    See this [1]
+
+    [1] https://example.com
+    └─── by commentuser 2 hours ago
+`
+	expectedMarkdownOutput := `* **[Story title](www.story.url)**
+* └─── 10 pts by [storyuser](https://news.ycombinator.com/user?id=storyuser) 12 days ago | [20 comments](https://news.ycombinator.com/item?id=2)
+  * First *paragraph*.
+
+    See [this](https://example.com)
+  * └─── by [commentuser](https://news.ycombinator.com/user?id=commentuser) [2 hours ago](https://news.ycombinator.com/item?id=11)
+`
+	assert.Equal(t, expectedPlainOutput, plainOutput.String())
+	assert.Equal(t, expectedMarkdownOutput, markdownOutput.String())
+}
+
+func TestThreadPlainOutputWrapsToWidth(t *testing.T) {
+	thread := api.Thread{
+		Item: story,
+		Replies: []api.Thread{
+			{
+				Item: api.Item{
+					Id:   11,
+					Type: api.Comment,
+					By:   ptr("commentuser"),
+					Time: ptr(now.Add(-2 * time.Hour).Unix()), // 2 hours ago
+					Text: ptr("Größere Änderungen kommen später.<p><pre><code>a very long line of code that is not wrapped</code></pre>"),
+				},
+			},
+		},
+	}
+	opts := Options{Clock: &fakeClock, Width: 24}
+
+	var output bytes.Buffer
+	WriteThreadPlain(&thread, opts, &output)
+
+	expectedOutput := `www.story.url
+└─── 10 pts by storyuser 12 days ago | 20 comments
+    Größere Änderungen
+    kommen später.
+
+        a very long line of code that is not wrapped
+    └─── by commentuser 2 hours ago
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
@@ -3,7 +3,10 @@ package formatting
 import (
 	"fmt"
 	"math"
+	"strconv"
 	"time"
+
+	"github.com/fmenozzi/hn/src/api"
 )
 
 type Clock interface {
@@ -22,36 +25,115 @@ var (
 	year  = 12 * month
 )
 
+// How far in the future timestamps can be and still be rendered as relative
+// times, to allow for clock skew between us and HN. Timestamps further in the
+// future are rendered as absolute times instead.
+const futureSkewTolerance = 5 * time.Minute
+
+// How times are rendered in plain, markdown and html output.
+type TimeFormat string
+
+const (
+	// E.g. "2 hours ago".
+	RelativeTime TimeFormat = "relative"
+
+	// E.g. "2006-01-02T15:04:05Z".
+	IsoTime TimeFormat = "iso"
+
+	// E.g. "2006-01-02 15:04".
+	LocalTime TimeFormat = "local"
+
+	// E.g. "1136214245".
+	UnixTime TimeFormat = "unix"
+)
+
+func ParseTimeFormat(name string) (TimeFormat, error) {
+	switch format := TimeFormat(name); format {
+	case RelativeTime, IsoTime, LocalTime, UnixTime:
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid time format: %s\n", name)
+	}
+}
+
 // Converts a timestamp from the past to a high-level human-readable time
 // string (e.g. "2 minutes ago", "5 years ago", etc).
 func GetRelativeTime(clock Clock, timestamp time.Time) string {
+	return GetLocalizedRelativeTime(clock, timestamp, English)
+}
+
+// Like `GetRelativeTime`, but in the given locale. Timestamps slightly in the
+// future are rendered as just now, and those any further in the future, e.g.
+// because our clock is well behind, as ISO 8601 times in UTC.
+func GetLocalizedRelativeTime(clock Clock, timestamp time.Time, locale *Locale) string {
 	now := clock.Now()
+	if timestamp.After(now.Add(futureSkewTolerance)) {
+		return timestamp.UTC().Format(time.RFC3339)
+	}
 	if timestamp.After(now) {
-		panic(fmt.Sprintf("cannot get relative time for future time %v", timestamp))
+		return locale.JustNow
 	}
 
 	elapsed := now.Sub(timestamp)
 
 	switch {
 	case elapsed < 90*time.Second:
-		return "a minute ago"
+		return locale.Minute
 	case elapsed < 50*time.Minute:
-		return fmt.Sprintf("%.0f min ago", math.Ceil(elapsed.Minutes()))
+		return fmt.Sprintf(locale.Minutes, int(math.Ceil(elapsed.Minutes())))
 	case elapsed < 90*time.Minute:
-		return "an hour ago"
+		return locale.Hour
 	case elapsed < 21*time.Hour:
-		return fmt.Sprintf("%.0f hours ago", math.Ceil(elapsed.Hours()))
+		return fmt.Sprintf(locale.Hours, int(math.Ceil(elapsed.Hours())))
 	case elapsed < 36*time.Hour:
-		return "a day ago"
+		return locale.Day
 	case elapsed < 25*day:
-		return fmt.Sprintf("%.0f days ago", math.Ceil(elapsed.Hours()/24.0))
+		return fmt.Sprintf(locale.Days, int(math.Ceil(elapsed.Hours()/24.0)))
 	case elapsed < 45*day:
-		return "a month ago"
+		return locale.Month
 	case elapsed < 11*month:
-		return fmt.Sprintf("%.0f months ago", math.Ceil(elapsed.Hours()/(24.0*30)))
+		return fmt.Sprintf(locale.Months, int(math.Ceil(elapsed.Hours()/(24.0*30))))
 	case elapsed < 17*month:
-		return "a year ago"
+		return locale.Year
 	default:
-		return fmt.Sprintf("%.0f years ago", math.Ceil(elapsed.Hours()/(24.0*30*12)))
+		return fmt.Sprintf(locale.Years, int(math.Ceil(elapsed.Hours()/(24.0*30*12))))
+	}
+}
+
+// Renders the timestamp in the time format and locale of the options. Absolute
+// times are in the options' location, which defaults to UTC for iso times and
+// to the local time zone for local times.
+func (opts *Options) formatTime(timestamp time.Time) string {
+	switch opts.Time {
+	case IsoTime:
+		return timestamp.In(opts.location(time.UTC)).Format(time.RFC3339)
+	case LocalTime:
+		return timestamp.In(opts.location(time.Local)).Format("2006-01-02 15:04")
+	case UnixTime:
+		return strconv.FormatInt(timestamp.Unix(), 10)
+	default:
+		return GetLocalizedRelativeTime(opts.Clock, timestamp, opts.locale())
+	}
+}
+
+// Renders the item's time as per `formatTime`, if it has one.
+func (opts *Options) itemTime(item *api.Item) string {
+	if item.Time == nil {
+		return opts.locale().Unknown
+	}
+	return opts.formatTime(time.Unix(*item.Time, 0))
+}
+
+func (opts *Options) location(or *time.Location) *time.Location {
+	if opts.Location != nil {
+		return opts.Location
+	}
+	return or
+}
+
+func (opts *Options) locale() *Locale {
+	if opts.Locale != nil {
+		return opts.Locale
 	}
+	return English
 }
@@ -58,12 +58,81 @@ func TestRelativeTime(t *testing.T) {
 	assert.Equal(t, "4 years ago", GetRelativeTime(&clock, fourYearsAgo))
 }
 
-func TestRelativeTimePanicsWithFutureValues(t *testing.T) {
+func TestRelativeTimeFallsBackToAbsoluteTimeForFutureValues(t *testing.T) {
 	now := time.Unix(10000000, 0)
 	clock := FakeClock{now}
 
-	assert.Panics(t, func() {
+	assert.NotPanics(t, func() {
 		oneMonthFromNow := now.Add(month)
-		GetRelativeTime(&clock, oneMonthFromNow)
-	}, "cannot get relative time for future time")
+		assert.Equal(t, "1970-05-26T17:46:40Z", GetRelativeTime(&clock, oneMonthFromNow))
+	})
+}
+
+func TestRelativeTimeToleratesFutureSkew(t *testing.T) {
+	now := time.Unix(10000000, 0)
+	clock := FakeClock{now}
+
+	assert.Equal(t, "just now", GetRelativeTime(&clock, now.Add(5*time.Second)))
+	assert.Equal(t, "just now", GetRelativeTime(&clock, now.Add(futureSkewTolerance)))
+	assert.Equal(t, "a minute ago", GetRelativeTime(&clock, now))
+	assert.Equal(t, "1970-04-26T17:51:41Z", GetRelativeTime(&clock, now.Add(futureSkewTolerance+time.Second)))
+}
+
+func TestLocalizedRelativeTime(t *testing.T) {
+	now := time.Unix(10000000, 0)
+	clock := FakeClock{now}
+
+	italian, err := ParseLocale("it")
+	assert.Nil(t, err)
+
+	assert.Equal(t, "proprio ora", GetLocalizedRelativeTime(&clock, now.Add(time.Second), italian))
+	assert.Equal(t, "un minuto fa", GetLocalizedRelativeTime(&clock, now.Add(-5*time.Second), italian))
+	assert.Equal(t, "45 min fa", GetLocalizedRelativeTime(&clock, now.Add(-45*time.Minute), italian))
+	assert.Equal(t, "2 ore fa", GetLocalizedRelativeTime(&clock, now.Add(-2*time.Hour), italian))
+	assert.Equal(t, "6 giorni fa", GetLocalizedRelativeTime(&clock, now.Add(-6*day), italian))
+	assert.Equal(t, "10 mesi fa", GetLocalizedRelativeTime(&clock, now.Add(-10*month), italian))
+	assert.Equal(t, "4 anni fa", GetLocalizedRelativeTime(&clock, now.Add(-4*year), italian))
+}
+
+func TestParseLocale(t *testing.T) {
+	locale, err := ParseLocale("en")
+	assert.Nil(t, err)
+	assert.Equal(t, English, locale)
+
+	_, err = ParseLocale("xx")
+	assert.Equal(t, "invalid locale: xx\n", err.Error())
+
+	assert.Equal(t, []string{"de", "en", "es", "fr", "it"}, LocaleNames())
+}
+
+func TestParseTimeFormat(t *testing.T) {
+	for _, name := range []string{"relative", "iso", "local", "unix"} {
+		format, err := ParseTimeFormat(name)
+		assert.Nil(t, err)
+		assert.Equal(t, TimeFormat(name), format)
+	}
+
+	_, err := ParseTimeFormat("rfc822")
+	assert.Equal(t, "invalid time format: rfc822\n", err.Error())
+}
+
+func TestFormatTime(t *testing.T) {
+	now := time.Unix(10000000, 0)
+	clock := FakeClock{now}
+	timestamp := now.Add(-2 * time.Hour)
+	rome := time.FixedZone("CET", 60*60)
+
+	relative := Options{Clock: &clock}
+	iso := Options{Clock: &clock, Time: IsoTime}
+	isoRome := Options{Clock: &clock, Time: IsoTime, Location: rome}
+	localRome := Options{Clock: &clock, Time: LocalTime, Location: rome}
+	unix := Options{Clock: &clock, Time: UnixTime}
+	german := Options{Clock: &clock, Locale: locales["de"]}
+
+	assert.Equal(t, "2 hours ago", relative.formatTime(timestamp))
+	assert.Equal(t, "1970-04-26T15:46:40Z", iso.formatTime(timestamp))
+	assert.Equal(t, "1970-04-26T16:46:40+01:00", isoRome.formatTime(timestamp))
+	assert.Equal(t, "1970-04-26 16:46", localRome.formatTime(timestamp))
+	assert.Equal(t, "9992800", unix.formatTime(timestamp))
+	assert.Equal(t, "vor 2 Stunden", german.formatTime(timestamp))
 }
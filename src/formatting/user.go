@@ -0,0 +1,34 @@
+package formatting
+
+import (
+	"io"
+	"strings"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+func WriteUserPlain(user *api.User, opts Options, w io.Writer) {
+	NewPlainFormatter(opts).WriteUser(user, w)
+}
+
+func WriteUserMarkdown(user *api.User, opts Options, w io.Writer) {
+	NewMarkdownFormatter(opts).WriteUser(user, w)
+}
+
+func WriteUserJson(user *api.User, w io.Writer) {
+	NewJsonFormatter(Options{}).WriteUser(user, w)
+}
+
+func WriteUserCsv(user *api.User, w io.Writer) {
+	NewCsvFormatter(Options{}).WriteUser(user, w)
+}
+
+func nonEmptyLines(text []string) []string {
+	var lines []string
+	for _, line := range text {
+		if line = strings.TrimRight(line, " \t"); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,147 @@
+package formatting
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+var user = api.User{
+	Id:        "username",
+	Created:   now.Add(-20 * month).Unix(), // 2 years ago
+	Karma:     1234,
+	About:     ptr("About text\nSecond line"),
+	Submitted: []api.ItemId{1, 2, 3},
+}
+
+func TestUserPlainOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteUserPlain(&user, fakeOptions, &output)
+
+	expectedOutput := "username\n│    About text\n│    Second line\n└─── 1234 karma | joined 2 years ago | 3 submissions\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestUserMarkdownOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteUserMarkdown(&user, fakeOptions, &output)
+
+	expectedOutput := "* **[username](https://news.ycombinator.com/user?id=username)**\n* About text\n* Second line\n* └─── 1234 karma | joined 2 years ago | 3 submissions\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestUserJsonOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteUserJson(&user, &output)
+
+	expectedOutput := `{
+	"id": "username",
+	"created": -41840000,
+	"karma": 1234,
+	"about": "About text\nSecond line",
+	"submitted": [
+		1,
+		2,
+		3
+	]
+}
+`
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestUserCsvOutput(t *testing.T) {
+	var output bytes.Buffer
+	WriteUserCsv(&user, &output)
+
+	expectedOutput := "username,-41840000,1234,\"About text\nSecond line\",\"1,2,3\"\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestUserWithoutAboutOutput(t *testing.T) {
+	user := user
+	user.About = nil
+	user.Submitted = []api.ItemId{1}
+
+	var output bytes.Buffer
+	WriteUserPlain(&user, fakeOptions, &output)
+
+	expectedOutput := "username\n└─── 1234 karma | joined 2 years ago | 1 submission\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestUserPlainOutputWrapsToWidth(t *testing.T) {
+	user := user
+	user.About = ptr("Écrivain, développeur et amateur de café")
+	opts := Options{Clock: &fakeClock, Width: 25}
+
+	var output bytes.Buffer
+	WriteUserPlain(&user, opts, &output)
+
+	expectedOutput := "username\n│    Écrivain,\n│    développeur et\n│    amateur de café\n└─── 1234 karma | joined 2 years ago | 3 submissions\n"
+
+	assert.Equal(t, expectedOutput, output.String())
+}
+
+func TestUserWithSubmissionsJsonOutputIsOneDocument(t *testing.T) {
+	var output, fieldsOutput, noSubmissionsOutput bytes.Buffer
+	user := user
+	user.About = nil
+
+	WriteUser(NewJsonFormatter(fakeOptions), &user, []api.Item{{Id: 1, Type: api.Story}}, &output)
+	opts := Options{Clock: &fakeClock, Fields: []string{"id", "type"}}
+	WriteUser(NewJsonFormatter(opts), &user, []api.Item{{Id: 1, Type: api.Story}, {Id: 2, Type: api.Job}}, &fieldsOutput)
+	WriteUser(NewJsonFormatter(fakeOptions), &user, []api.Item{}, &noSubmissionsOutput)
+
+	var decoded map[string]any
+	assert.Nil(t, json.Unmarshal(output.Bytes(), &decoded))
+	assert.Equal(t, "username", decoded["id"])
+	assert.Len(t, decoded["submissions"], 1)
+	assert.Equal(t, `{
+	"id": "username",
+	"created": -41840000,
+	"karma": 1234,
+	"about": null,
+	"submitted": [
+		1,
+		2,
+		3
+	],
+	"submissions": [
+		{
+			"id": 1,
+			"type": "story"
+		},
+		{
+			"id": 2,
+			"type": "job"
+		}
+	]
+}
+`, fieldsOutput.String())
+	assert.Contains(t, noSubmissionsOutput.String(), "\"submissions\": []\n}\n")
+}
+
+func TestUserWithoutSubmissionsAskedForIsWrittenAlone(t *testing.T) {
+	var output, expectedOutput bytes.Buffer
+
+	WriteUser(NewJsonFormatter(fakeOptions), &user, nil, &output)
+	WriteUserJson(&user, &expectedOutput)
+
+	assert.Equal(t, expectedOutput.String(), output.String())
+}
+
+func TestUserWithSubmissionsPlainOutputIsFollowedByItems(t *testing.T) {
+	var output bytes.Buffer
+
+	WriteUser(NewPlainFormatter(fakeOptions), &user, []api.Item{story}, &output)
+
+	assert.Equal(t, "username\n│    About text\n│    Second line\n└─── 1234 karma | joined 2 years ago | 3 submissions\n"+
+		"www.story.url\n└─── 10 pts by storyuser 12 days ago | 20 comments\n", output.String())
+}
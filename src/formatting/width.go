@@ -0,0 +1,151 @@
+package formatting
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const ellipsis = "..."
+
+// Returns the number of terminal columns the string takes up.
+func StringWidth(s string) int {
+	width := 0
+	for len(s) > 0 {
+		cluster, rest := nextCluster(s)
+		width += clusterWidth(cluster)
+		s = rest
+	}
+	return width
+}
+
+// Shortens the string to fit within the given number of terminal columns,
+// marking the cut with an ellipsis. Never splits a character, including
+// characters made up of multiple code points such as accented letters or
+// emoji sequences.
+func Truncate(s string, width int) string {
+	if StringWidth(s) <= width {
+		return s
+	}
+	suffix := ellipsis
+	if width < len(ellipsis) {
+		suffix = ""
+	}
+	var b strings.Builder
+	remaining := width - len(suffix)
+	for len(s) > 0 {
+		cluster, rest := nextCluster(s)
+		clusterWidth := clusterWidth(cluster)
+		if clusterWidth > remaining {
+			break
+		}
+		b.WriteString(cluster)
+		remaining -= clusterWidth
+		s = rest
+	}
+	b.WriteString(suffix)
+	return b.String()
+}
+
+// Breaks the string into lines of at most the given number of terminal
+// columns, breaking between words. Words wider than a line get a line of
+// their own rather than being split.
+func Wrap(s string, width int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+	for _, word := range strings.Fields(s) {
+		wordWidth := StringWidth(word)
+		if lineWidth > 0 && lineWidth+1+wordWidth > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineWidth = 0
+		}
+		if lineWidth > 0 {
+			line.WriteByte(' ')
+			lineWidth++
+		}
+		line.WriteString(word)
+		lineWidth += wordWidth
+	}
+	if lineWidth > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// Splits off the first user-perceived character of the string. This
+// approximates Unicode grapheme clusters, covering combining marks, variation
+// selectors, emoji modifiers, zero-width joiner sequences, and flags.
+func nextCluster(s string) (string, string) {
+	r, size := utf8.DecodeRuneInString(s)
+	end := size
+	regionalIndicators := 0
+	if isRegionalIndicator(r) {
+		regionalIndicators++
+	}
+	for end < len(s) {
+		next, nextSize := utf8.DecodeRuneInString(s[end:])
+		switch {
+		case isExtender(next):
+			end += nextSize
+		case r == '\u200d':
+			// The rune after a zero-width joiner is part of the same cluster.
+			end += nextSize
+		case isRegionalIndicator(next) && regionalIndicators == 1:
+			regionalIndicators++
+			end += nextSize
+		default:
+			return s[:end], s[end:]
+		}
+		r = next
+	}
+	return s[:end], s[end:]
+}
+
+// Reports whether the rune extends the preceding character rather than
+// starting a new one.
+func isExtender(r rune) bool {
+	return unicode.Is(unicode.Mn, r) ||
+		unicode.Is(unicode.Me, r) ||
+		r == '\u200d' || // zero-width joiner
+		(r >= '\ufe00' && r <= '\ufe0f') || // variation selectors
+		(r >= 0x1f3fb && r <= 0x1f3ff) // emoji skin tone modifiers
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+func clusterWidth(cluster string) int {
+	r, _ := utf8.DecodeRuneInString(cluster)
+	switch {
+	case isWide(r) || strings.ContainsRune(cluster, '\ufe0f'):
+		return 2
+	case unicode.IsControl(r) || isExtender(r):
+		return 0
+	default:
+		return 1
+	}
+}
+
+// Reports whether the rune is rendered two columns wide, i.e. East Asian wide
+// and fullwidth characters and emoji.
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115f) || // Hangul Jamo
+		(r >= 0x2e80 && r <= 0x303e) || // CJK radicals, punctuation
+		(r >= 0x3041 && r <= 0x33ff) || // Hiragana, Katakana, CJK symbols
+		(r >= 0x3400 && r <= 0x4dbf) || // CJK extension A
+		(r >= 0x4e00 && r <= 0x9fff) || // CJK unified ideographs
+		(r >= 0xa000 && r <= 0xa4cf) || // Yi
+		(r >= 0xac00 && r <= 0xd7a3) || // Hangul syllables
+		(r >= 0xf900 && r <= 0xfaff) || // CJK compatibility ideographs
+		(r >= 0xfe30 && r <= 0xfe4f) || // CJK compatibility forms
+		(r >= 0xff00 && r <= 0xff60) || // Fullwidth forms
+		(r >= 0xffe0 && r <= 0xffe6) ||
+		(r >= 0x1f1e6 && r <= 0x1f1ff) || // Regional indicators
+		(r >= 0x1f300 && r <= 0x1f64f) || // Emoji, pictographs
+		(r >= 0x1f900 && r <= 0x1f9ff) || // Supplemental symbols, pictographs
+		(r >= 0x1fa70 && r <= 0x1faff) ||
+		(r >= 0x20000 && r <= 0x3fffd) // CJK extensions B and beyond
+}
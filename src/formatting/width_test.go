@@ -0,0 +1,75 @@
+package formatting
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringWidth(t *testing.T) {
+	for _, test := range []struct {
+		s        string
+		expected int
+	}{
+		{"", 0},
+		{"ascii", 5},
+		{"café", 4},
+		{"cafe\u0301", 4}, // combining acute accent
+		{"日本語", 6},
+		{"한국어", 6},
+		{"👍", 2},
+		{"👍🏽", 2}, // skin tone modifier
+		{"\U0001f468\u200d\U0001f469\u200d\U0001f467", 2}, // zero-width joiner sequence
+		{"🇯🇵🇺🇸", 4},                                       // two flags
+		{"\u2764\ufe0f", 2},                               // emoji presentation selector
+		{"ｆｕｌｌ", 8},                                       // fullwidth
+		{"a\tb", 2},                                       // control characters take no space
+		{"Zoë 日本", 8},                                     // mixed
+	} {
+		assert.Equal(t, test.expected, StringWidth(test.s), test.s)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	family := "\U0001f468\u200d\U0001f469\u200d\U0001f467"
+
+	for _, test := range []struct {
+		s        string
+		width    int
+		expected string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is too long", 10, "this is..."},
+		{"café au lait", 7, "café..."},
+		{"cafe\u0301 au lait", 7, "cafe\u0301..."}, // combining acute accent
+		{"日本語のテキスト", 9, "日本語..."},
+		{"日本語のテキスト", 10, "日本語..."},
+		{"👍🏽👍🏽👍🏽👍🏽", 7, "👍🏽👍🏽..."},
+		{strings.Repeat(family, 3), 5, family + "..."},
+		{"🇯🇵🇺🇸🇫🇷", 5, "🇯🇵..."},
+		{"abcdef", 2, "ab"},
+		{"abcdef", 0, ""},
+	} {
+		assert.Equal(t, test.expected, Truncate(test.s, test.width), test.s)
+	}
+}
+
+func TestWrap(t *testing.T) {
+	for _, test := range []struct {
+		s        string
+		width    int
+		expected []string
+	}{
+		{"", 10, []string{""}},
+		{"fits on a line", 20, []string{"fits on a line"}},
+		{"the quick brown fox jumps over the lazy dog", 10, []string{"the quick", "brown fox", "jumps over", "the lazy", "dog"}},
+		{"a https://example.com/very/long/url b", 10, []string{"a", "https://example.com/very/long/url", "b"}},
+		{"日本語 の テキスト です", 10, []string{"日本語 の", "テキスト", "です"}},
+		{"Zoë und Björn sind da", 9, []string{"Zoë und", "Björn", "sind da"}},
+		{"collapses   extra\nwhitespace", 30, []string{"collapses extra whitespace"}},
+	} {
+		assert.Equal(t, test.expected, Wrap(test.s, test.width), test.s)
+	}
+}
@@ -0,0 +1,93 @@
+// Package sorting reorders fetched items, e.g. by score instead of HN rank.
+package sorting
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fmenozzi/hn/src/api"
+)
+
+// What items are sorted by.
+type Key string
+
+const (
+	// Highest score first.
+	Score Key = "score"
+
+	// Most comments first.
+	Comments Key = "comments"
+
+	// Newest first.
+	Time Key = "time"
+
+	// Alphabetically by title, ignoring case.
+	Title Key = "title"
+)
+
+// Compares two items, returning a negative number if a comes before b, a
+// positive number if b comes before a, and zero if either can come first.
+type Comparator func(a, b *api.Item) int
+
+func ParseKey(name string) (Key, error) {
+	switch key := Key(name); key {
+	case Score, Comments, Time, Title:
+		return key, nil
+	default:
+		return "", fmt.Errorf("invalid sort: %s\n", name)
+	}
+}
+
+// Returns the comparator for the key, in reverse order if asked. Either way,
+// items without the field sorted by come last.
+func NewComparator(key Key, reverse bool) Comparator {
+	switch key {
+	case Score:
+		return byField(func(item *api.Item) *int32 { return item.Score }, !reverse)
+	case Comments:
+		return byField(func(item *api.Item) *int32 { return item.Descendants }, !reverse)
+	case Time:
+		return byField(func(item *api.Item) *int64 { return item.Time }, !reverse)
+	case Title:
+		return byField(lowerTitle, reverse)
+	default:
+		panic(fmt.Sprintf("invalid sort: %s", key))
+	}
+}
+
+// Sorts the items with the comparator. Items that compare equal keep their
+// order, e.g. their rank on the front page.
+func Sort(items []api.Item, comparator Comparator) {
+	slices.SortStableFunc(items, func(a, b api.Item) int {
+		return comparator(&a, &b)
+	})
+}
+
+// Compares items by the field, with those without it last.
+func byField[T cmp.Ordered](field func(item *api.Item) *T, descending bool) Comparator {
+	return func(a, b *api.Item) int {
+		x, y := field(a), field(b)
+		switch {
+		case x == nil && y == nil:
+			return 0
+		case x == nil:
+			return 1
+		case y == nil:
+			return -1
+		case descending:
+			return cmp.Compare(*y, *x)
+		default:
+			return cmp.Compare(*x, *y)
+		}
+	}
+}
+
+func lowerTitle(item *api.Item) *string {
+	if item.Title == nil {
+		return nil
+	}
+	title := strings.ToLower(*item.Title)
+	return &title
+}
@@ -0,0 +1,55 @@
+package sorting
+
+import (
+	"testing"
+
+	"github.com/fmenozzi/hn/src/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func ptr[T any](t T) *T {
+	return &t
+}
+
+var items = []api.Item{
+	{Id: 1, Type: api.Story, Score: ptr(int32(10)), Descendants: ptr(int32(5)), Time: ptr(int64(300)), Title: ptr("banana")},
+	{Id: 2, Type: api.Job, Score: ptr(int32(1)), Time: ptr(int64(100)), Title: ptr("Apple")},
+	{Id: 3, Type: api.Story, Score: ptr(int32(50)), Descendants: ptr(int32(5)), Time: ptr(int64(200)), Title: ptr("cherry")},
+	{Id: 4, Type: api.Comment},
+	{Id: 5, Type: api.Story, Score: ptr(int32(10)), Descendants: ptr(int32(80)), Title: ptr("apple pie")},
+}
+
+func sortedIds(key Key, reverse bool) []api.ItemId {
+	sorted := append([]api.Item{}, items...)
+	Sort(sorted, NewComparator(key, reverse))
+	ids := []api.ItemId{}
+	for _, item := range sorted {
+		ids = append(ids, item.Id)
+	}
+	return ids
+}
+
+func TestSort(t *testing.T) {
+	assert.Equal(t, []api.ItemId{3, 1, 5, 2, 4}, sortedIds(Score, false))
+	assert.Equal(t, []api.ItemId{5, 1, 3, 2, 4}, sortedIds(Comments, false))
+	assert.Equal(t, []api.ItemId{1, 3, 2, 4, 5}, sortedIds(Time, false))
+	assert.Equal(t, []api.ItemId{2, 5, 1, 3, 4}, sortedIds(Title, false))
+}
+
+func TestSortReversedKeepsMissingFieldsLast(t *testing.T) {
+	assert.Equal(t, []api.ItemId{2, 1, 5, 3, 4}, sortedIds(Score, true))
+	assert.Equal(t, []api.ItemId{1, 3, 5, 2, 4}, sortedIds(Comments, true))
+	assert.Equal(t, []api.ItemId{2, 3, 1, 4, 5}, sortedIds(Time, true))
+	assert.Equal(t, []api.ItemId{3, 1, 5, 2, 4}, sortedIds(Title, true))
+}
+
+func TestParseKey(t *testing.T) {
+	for _, name := range []string{"score", "comments", "time", "title"} {
+		key, err := ParseKey(name)
+		assert.Nil(t, err)
+		assert.Equal(t, Key(name), key)
+	}
+
+	_, err := ParseKey("rank")
+	assert.Equal(t, "invalid sort: rank\n", err.Error())
+}